@@ -0,0 +1,151 @@
+// options.go: functional options for Client
+
+package witai
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// option for NewClientWithOptions
+type Option func(c *Client)
+
+// use given API version
+//
+// panics if given version is not in YYYYMMDD format
+func WithVersion(version string) Option {
+	if err := ValidateVersion(version); err != nil {
+		panic(fmt.Sprintf("witai: %s", err))
+	}
+
+	return func(c *Client) {
+		headerAccept := fmt.Sprintf("application/vnd.wit.%s+json", version)
+
+		c.Version = &version
+		c.headerAccept = &headerAccept
+	}
+}
+
+// print verbose messages or not
+func WithVerbose(verbose bool) Option {
+	return func(c *Client) {
+		c.Verbose = verbose
+	}
+}
+
+// send requests with given http client
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.HTTPClient = client
+	}
+}
+
+// send requests with given Doer instead of the http client (eg. a fake for unit tests)
+func WithDoer(doer Doer) Option {
+	return func(c *Client) {
+		c.Doer = doer
+	}
+}
+
+// send requests to given base url (eg. for mock servers or proxies)
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.BaseURL = baseURL
+	}
+}
+
+// time out requests after given duration
+//
+// the http client given with WithHTTPClient is copied, not modified
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		client := copiedHTTPClient(c)
+		client.Timeout = timeout
+
+		c.HTTPClient = client
+	}
+}
+
+// send requests with given transport (eg. with a proxy or custom dialer)
+//
+// the http client given with WithHTTPClient is copied, not modified
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) {
+		client := copiedHTTPClient(c)
+		client.Transport = transport
+
+		c.HTTPClient = client
+	}
+}
+
+// send requests with given tls config (eg. with a custom CA for a proxy)
+//
+// the http client given with WithHTTPClient (and its transport) is copied, not modified
+func WithTLSConfig(config *tls.Config) Option {
+	return func(c *Client) {
+		client := copiedHTTPClient(c)
+
+		transport, ok := client.Transport.(*http.Transport)
+		if ok {
+			transport = transport.Clone()
+		} else {
+			transport = http.DefaultTransport.(*http.Transport).Clone()
+		}
+		transport.TLSClientConfig = config
+		client.Transport = transport
+
+		c.HTTPClient = client
+	}
+}
+
+// copy of the client's http client (or a new one with its own transport) for modifying it
+func copiedHTTPClient(c *Client) *http.Client {
+	client := http.Client{
+		Transport: http.DefaultTransport.(*http.Transport).Clone(),
+	}
+	if c.HTTPClient != nil {
+		client = *c.HTTPClient
+	}
+	return &client
+}
+
+// skip validating wav headers before uploading wav files or not
+func WithSkipWavValidation(skip bool) Option {
+	return func(c *Client) {
+		c.SkipWavValidation = skip
+	}
+}
+
+// query messages and speeches with given version tag of the app
+//
+// https://wit.ai/docs/http/20200513#get__message_link
+func WithTag(tag string) Option {
+	return func(c *Client) {
+		c.Tag = tag
+	}
+}
+
+// send requests with given User-Agent header
+//
+// for appending an app's name, eg: WithUserAgent(witai.DefaultUserAgent + " my-app/1.0")
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.UserAgent = userAgent
+	}
+}
+
+// print verbose messages with given logger (nothing is printed when nil)
+func WithLogger(logger Logger) Option {
+	return func(c *Client) {
+		c.Logger = logger
+	}
+}
+
+// limit the size of response bodies (unlimited when negative)
+func WithMaxResponseBytes(maxBytes int64) Option {
+	return func(c *Client) {
+		c.MaxResponseBytes = maxBytes
+	}
+}
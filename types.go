@@ -2,14 +2,85 @@
 
 package witai
 
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// wit.ai API client
+//
+// it is safe for concurrent use by multiple goroutines,
+// but its fields should not be changed after it is shared
 type Client struct {
 	Token   *string
 	Version *string
+	BaseURL string // DefaultBaseURL when empty
+	Tag     string // version tag of the app for message and speech queries (latest when empty)
+
+	HTTPClient *http.Client // http.DefaultClient when nil
+	Doer       Doer         // sends requests instead of HTTPClient when not nil (eg. a fake for unit tests)
+
+	UserAgent string            // DefaultUserAgent when empty
+	Headers   map[string]string // custom headers for every request (see also ContextWithHeaders)
 
 	headerAuth   *string
 	headerAccept *string
 
-	Verbose bool
+	Verbose         bool
+	RedactSensitive bool   // redact the token and request bodies in verbose messages
+	Logger          Logger // verbose messages are printed with this (standard logger by default, nothing is printed when nil)
+
+	Retry *RetryConfig // no retries when nil
+
+	CompressRequests bool // gzip json request bodies (of 1KB or larger)
+
+	MaxResponseBytes int64 // max size of response bodies (DefaultMaxResponseBytes when 0, unlimited when negative)
+
+	SkipWavValidation bool // do not validate wav headers before uploading wav files
+
+	// called after every HTTP round trip (statusCode is 0 when no response was received)
+	OnRequestComplete func(method, url string, statusCode int, duration time.Duration, err error)
+
+	mutex     sync.Mutex // guards fields below, which are updated on every response
+	rateLimit *RateLimit
+}
+
+// rate limit values from `X-RateLimit-*` response headers
+type RateLimit struct {
+	Limit     int // zero when not given
+	Remaining int
+	Reset     time.Time // zero when not given
+	UpdatedAt time.Time
+}
+
+// retry configuration for 429 and 5xx responses
+//
+// only GET requests are retried, unless RetrySpeech is set;
+// the last error is returned with the number of attempts made
+type RetryConfig struct {
+	MaxAttempts    int           // including the first attempt
+	Backoff        time.Duration // base backoff duration, doubled on each retry (up to MaxRetryBackoff)
+	Jitter         bool          // wait for a random duration between 0 and the backoff duration (full jitter)
+	MaxElapsedTime time.Duration // give up retrying when the total time would exceed this (no limit when 0)
+
+	RetrySpeech bool // also retry POST requests of speech (only for seekable readers)
+}
+
+// sender of http requests (*http.Client satisfies it)
+//
+// eg. for returning canned responses in unit tests, without sending requests to wit.ai
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// function which satisfies Doer
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+// logger for verbose messages
+type Logger interface {
+	Printf(format string, v ...interface{})
 }
 
 // https://wit.ai/docs/http/20160330#response-format-link
@@ -20,15 +91,61 @@ type ResponseError struct {
 	Code   *string  `json:"code,omitempty"`
 }
 
+// error returned when the API responds with an error status code (>= 400)
+type APIError struct {
+	StatusCode int
+	Body       string
+	Message    string // parsed error message (empty when the body could not be parsed)
+	Code       string // parsed error code (empty when not given)
+	HTML       bool   // the body is a html page (eg. from a proxy or a gateway), not a response of wit.ai
+}
+
+// response of delete requests
+type DeletedResponse struct {
+	ResponseError
+
+	Deleted *string // name or id of the deleted item (json-encoded when it was not a string)
+	Success bool
+
+	Raw string // raw response body
+}
+
 // https://wit.ai/docs/http/20160330#converse-link
 type Converse struct {
 	ResponseError
 
+	MessageId  *string                `json:"msg_id,omitempty"`
 	Type       *string                `json:"type,omitempty"`
 	Message    *string                `json:"msg,omitempty"`
 	Action     *string                `json:"action,omitempty"`
 	Entities   map[string]interface{} `json:"entities,omitempty"`
 	Confidence float32                `json:"confidence"`
+
+	RawJSON json.RawMessage `json:"-"` // payload as it was received; json.Marshal does not round-trip it (unknown fields are dropped), so forward this instead
+}
+
+// handler of converse steps for Client.RunConverse
+type ConverseHandler interface {
+	// called on 'msg' steps
+	Say(msg string)
+
+	// called on 'merge' steps, returns the context for the next step
+	Merge(entities map[string]interface{}, witContext interface{}) interface{}
+
+	// called on 'action' steps, returns the context for the next step
+	Action(name string, witContext interface{}) interface{}
+}
+
+// context sent back on the next converse steps
+//
+// https://wit.ai/docs/http/20160516#post--converse-link
+type ConverseContext struct {
+	State         []string  `json:"state,omitempty"` // state flags
+	ReferenceTime *string   `json:"reference_time,omitempty"`
+	TimeZone      *string   `json:"timezone,omitempty"`
+	Location      *Location `json:"location,omitempty"`
+
+	Values map[string]interface{} `json:"-"` // merged values (eg. entity values on 'merge' steps), sent as keys of the context
 }
 
 // https://wit.ai/docs/http/20160330#context-link
@@ -55,9 +172,12 @@ type Location struct {
 type Message struct {
 	ResponseError
 
-	MessageId *string   `json:"msg_id"`
-	Text      *string   `json:"_text"`
-	Outcomes  []Outcome `json:"outcomes"`
+	MessageId *string                    `json:"msg_id"`
+	Text      *string                    `json:"_text"`
+	Outcomes  []Outcome                  `json:"outcomes"`
+	Traits    map[string][]DetectedTrait `json:"traits,omitempty"`
+
+	RawJSON json.RawMessage `json:"-"` // payload as it was received; json.Marshal does not round-trip it (unknown fields are dropped), so forward this instead
 }
 
 type Outcome struct {
@@ -67,6 +187,103 @@ type Outcome struct {
 	Confidence float32                `json:"confidence"`
 }
 
+// https://wit.ai/docs/http/20200513#get__language_link
+type LanguageDetection struct {
+	ResponseError
+
+	DetectedLocales []DetectedLocale `json:"detected_locales"`
+}
+
+type DetectedLocale struct {
+	Locale     *string `json:"locale"`
+	Confidence float32 `json:"confidence"`
+}
+
+// https://wit.ai/docs/http/20200513#get__message_link
+type MessageV2 struct {
+	ResponseError
+
+	MessageId *string                     `json:"msg_id,omitempty"`
+	Text      *string                     `json:"text"`
+	Intents   []DetectedIntent            `json:"intents"`
+	Entities  map[string][]DetectedEntity `json:"entities"` // key: "name:role"
+	Traits    map[string][]DetectedTrait  `json:"traits"`
+
+	RawJSON json.RawMessage `json:"-"` // payload as it was received; json.Marshal does not round-trip it (unknown fields are dropped), so forward this instead
+}
+
+// options for Client.QueryMessageWithOptions
+type MessageOptions struct {
+	N         int      // number of intents to return (1 when not positive)
+	Threshold *float32 // minimum confidence of intents, entities, and traits (not filtered when nil)
+	Locale    string   // locale of the query for multilingual apps (eg. "en_US", detected automatically when empty)
+	Tag       string   // version tag of the app for this query (Client.Tag when empty)
+	MessageId string   // client-supplied id of this query (sent as `msg_id`, eg. a trace id for matching utterances in the console)
+
+	// dynamic entities for biasing recognition in this query only (key: entity name, value: keywords)
+	//
+	// https://wit.ai/docs/http/20200513#get__message_link
+	DynamicEntities map[string][]string
+}
+
+// intent detected in a message
+type DetectedIntent struct {
+	Id         *string `json:"id"`
+	Name       *string `json:"name"`
+	Confidence float32 `json:"confidence"`
+}
+
+// entity detected in a message
+type DetectedEntity struct {
+	Id         *string                     `json:"id"`
+	Name       *string                     `json:"name"`
+	Role       *string                     `json:"role"`
+	Start      int                         `json:"start"`
+	End        int                         `json:"end"`
+	Body       *string                     `json:"body"`
+	Confidence float32                     `json:"confidence"`
+	Type       *string                     `json:"type,omitempty"`
+	Value      interface{}                 `json:"value,omitempty"`
+	From       *IntervalEnd                `json:"from,omitempty"` // for "interval" type
+	To         *IntervalEnd                `json:"to,omitempty"`   // for "interval" type
+	Entities   map[string][]DetectedEntity `json:"entities,omitempty"`
+}
+
+// typed value of an entity in Outcome.Entities
+type EntityResolution struct {
+	Value      interface{}  `json:"value"`
+	Confidence float32      `json:"confidence"`
+	Type       *string      `json:"type,omitempty"`
+	Unit       *string      `json:"unit,omitempty"`
+	Role       *string      `json:"role,omitempty"`
+	From       *IntervalEnd `json:"from,omitempty"` // for "interval" type
+	To         *IntervalEnd `json:"to,omitempty"`   // for "interval" type
+}
+
+// an end of an entity value of "interval" type (eg. of "wit/datetime")
+type IntervalEnd struct {
+	Value interface{} `json:"value"`
+	Grain *string     `json:"grain,omitempty"`
+	Unit  *string     `json:"unit,omitempty"`
+}
+
+// sample for Client.EvaluateSamples
+type Sample struct {
+	Text   *string `json:"text"`
+	Intent *string `json:"intent,omitempty"` // expected intent (nil for no intent)
+}
+
+// result of a sample from Client.EvaluateSamples
+type SampleResult struct {
+	Sample Sample `json:"sample"`
+
+	Intent     *string `json:"intent,omitempty"` // predicted intent (nil when no intent was detected)
+	Confidence float32 `json:"confidence"`
+	Passed     bool    `json:"passed"`
+
+	Error error `json:"-"` // error of the query (Passed is false when it is not nil)
+}
+
 // https://wit.ai/docs/http/20160330#intents-post-link
 type Intent struct {
 	ResponseError
@@ -120,10 +337,18 @@ type IntentAttributes struct {
 	Doc      *string `json:"doc,omitempty"`
 }
 
+// https://wit.ai/docs/http/20200513#get__entities_link
+type EntitySummary struct {
+	Id   *string `json:"id"`
+	Name *string `json:"name"`
+}
+
 // https://wit.ai/docs/http/20160330#entities-post-link
 type Entity struct {
 	ResponseError
 
+	MessageId *string `json:"msg_id,omitempty"`
+
 	Id      *string       `json:"id"`
 	Name    *string       `json:"name"`
 	Doc     *string       `json:"doc"`
@@ -132,9 +357,174 @@ type Entity struct {
 	Exotic  bool          `json:"exotic"`
 	Builtin bool          `json:"builtin"`
 	Values  []EntityValue `json:"values"`
+
+	// for the newer entities API
+	Keywords []Keyword `json:"keywords,omitempty"`
+	Lookups  []string  `json:"lookups,omitempty"`
+	Roles    []string  `json:"roles,omitempty"`
+
+	RawJSON json.RawMessage `json:"-"` // payload as it was received; json.Marshal does not round-trip it (unknown fields are dropped), so forward this instead
 }
 
 type EntityValue struct {
 	Expressions []string `json:"expressions"`
 	Value       *string  `json:"value"`
 }
+
+// https://wit.ai/docs/http/20200513#post__entities__entity_keywords_link
+type Keyword struct {
+	Keyword  *string  `json:"keyword"`
+	Synonyms []string `json:"synonyms,omitempty"`
+}
+
+// https://wit.ai/docs/http/20200513#post__utterances_link
+type Utterance struct {
+	Text     *string           `json:"text"`
+	Intent   *string           `json:"intent,omitempty"`
+	Entities []UtteranceEntity `json:"entities"`
+	Traits   []UtteranceTrait  `json:"traits"`
+}
+
+type UtteranceEntity struct {
+	Entity   *string           `json:"entity"` // in "name:role" format
+	Start    int               `json:"start"`
+	End      int               `json:"end"`
+	Body     *string           `json:"body"`
+	Entities []UtteranceEntity `json:"entities"`
+}
+
+type UtteranceTrait struct {
+	Trait *string `json:"trait"`
+	Value *string `json:"value"`
+}
+
+type UtterancesResponse struct {
+	ResponseError
+
+	Sent bool `json:"sent"`
+	N    int  `json:"n"`
+}
+
+// https://wit.ai/docs/http/20200513#get__traits__trait_link
+type Trait struct {
+	ResponseError
+
+	Id     *string      `json:"id"`
+	Name   *string      `json:"name"`
+	Values []TraitValue `json:"values,omitempty"`
+}
+
+type TraitValue struct {
+	Id    *string `json:"id,omitempty"`
+	Value *string `json:"value"`
+}
+
+// trait detected in a message
+type DetectedTrait struct {
+	Id         *string `json:"id"`
+	Value      *string `json:"value"`
+	Confidence float32 `json:"confidence"`
+}
+
+// https://wit.ai/docs/http/20200513#get__export_link
+type Export struct {
+	ResponseError
+
+	Uri *string `json:"uri"`
+}
+
+// https://wit.ai/docs/http/20200513#post__import_link
+type ImportResult struct {
+	ResponseError
+
+	AppId   *string `json:"app_id"`
+	Version *string `json:"version"`
+	Branch  *string `json:"branch"`
+}
+
+// https://wit.ai/docs/http/20200513#get__apps__app_link
+type App struct {
+	ResponseError
+
+	Id           *string `json:"id"`
+	Name         *string `json:"name"`
+	Lang         *string `json:"lang"`
+	Private      bool    `json:"private"`
+	CreatedAt    *string `json:"created_at"`
+	LastTraining *string `json:"last_trained_at,omitempty"`
+
+	TrainingStatus           *string `json:"training_status,omitempty"` // TrainingStatusDone, TrainingStatusScheduled, or TrainingStatusOngoing
+	LastTrainingDurationSecs *int    `json:"last_training_duration_secs,omitempty"`
+}
+
+// https://wit.ai/docs/http/20200513#post__apps_link
+type AppCreated struct {
+	ResponseError
+
+	AppId       *string `json:"app_id"`
+	AccessToken *string `json:"access_token"`
+}
+
+// https://wit.ai/docs/http/20200513#put__apps__app_link
+type AppUpdated struct {
+	ResponseError
+
+	Success bool `json:"success"`
+}
+
+// https://wit.ai/docs/http/20200513#get__apps__app_tags_link
+type Tag struct {
+	Name      *string `json:"name"`
+	Desc      *string `json:"desc,omitempty"`
+	CreatedAt *string `json:"created_at"`
+	UpdatedAt *string `json:"updated_at"`
+}
+
+// https://wit.ai/docs/http/20200513#post__apps__app_tags_link
+type TagCreated struct {
+	ResponseError
+
+	Tag *string `json:"tag"`
+}
+
+// https://wit.ai/docs/http/20200513#put__apps__app_tags__tag_link
+type TagMoved struct {
+	ResponseError
+
+	Tag     *string `json:"tag"`
+	MovedTo *string `json:"moved_to"`
+}
+
+// https://wit.ai/docs/http/20240304#post__dictation_link
+type DictationResult struct {
+	Text   *string          `json:"text"`   // final transcription (of all final chunks)
+	Chunks []DictationChunk `json:"chunks"` // all partial and final chunks, in received order
+}
+
+type DictationChunk struct {
+	ResponseError
+
+	Text    *string          `json:"text"`
+	Speech  *DictationSpeech `json:"speech,omitempty"`
+	IsFinal bool             `json:"is_final"`
+}
+
+type DictationSpeech struct {
+	Confidence float32       `json:"confidence"`
+	Tokens     []SpeechToken `json:"tokens"`
+}
+
+type SpeechToken struct {
+	Token      *string `json:"token"`
+	Start      int     `json:"start"`
+	End        int     `json:"end"`
+	Confidence float32 `json:"confidence"`
+}
+
+// https://wit.ai/docs/http/20240304#get__voices_link
+type Voice struct {
+	Name   *string  `json:"name"`
+	Locale *string  `json:"locale"`
+	Gender *string  `json:"gender"`
+	Styles []string `json:"styles"`
+}
@@ -3,8 +3,14 @@
 package witai
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
 	"strings"
+	"time"
 )
 
 // https://wit.ai/docs/http/20160330#converse-link
@@ -16,6 +22,9 @@ func (c Converse) String() string {
 	if c.Code != nil {
 		attrs = append(attrs, fmt.Sprintf("Code: %s", *c.Code))
 	}
+	if c.MessageId != nil {
+		attrs = append(attrs, fmt.Sprintf("MessageId: %s", *c.MessageId))
+	}
 	if c.Type != nil {
 		attrs = append(attrs, fmt.Sprintf("Type: %s", *c.Type))
 	}
@@ -28,7 +37,37 @@ func (c Converse) String() string {
 	if c.Entities != nil {
 		attrs = append(attrs, fmt.Sprintf("Entities: %v", c.Entities))
 	}
-	attrs = append(attrs, fmt.Sprintf("Confidence: %.6f", c.Confidence))
+	if c.hasConfidence() {
+		attrs = append(attrs, fmt.Sprintf("Confidence: %.6f", c.Confidence))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+// whether the confidence of this step is meaningful
+// (not for error responses, nor for empty steps without type and confidence)
+func (c Converse) hasConfidence() bool {
+	return !c.HasError() && (c.Type != nil || c.Confidence != 0)
+}
+
+// https://wit.ai/docs/http/20160516#post--converse-link
+func (c ConverseContext) String() string {
+	attrs := []string{}
+	if len(c.State) > 0 {
+		attrs = append(attrs, fmt.Sprintf("State: %v", c.State))
+	}
+	if c.ReferenceTime != nil {
+		attrs = append(attrs, fmt.Sprintf("ReferenceTime: %s", *c.ReferenceTime))
+	}
+	if c.TimeZone != nil {
+		attrs = append(attrs, fmt.Sprintf("TimeZone: %s", *c.TimeZone))
+	}
+	if c.Location != nil {
+		attrs = append(attrs, fmt.Sprintf("Location: %v", c.Location))
+	}
+	if len(c.Values) > 0 {
+		attrs = append(attrs, fmt.Sprintf("Values: %v", c.Values))
+	}
 
 	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
 }
@@ -90,6 +129,9 @@ func (m Message) String() string {
 	if len(m.Outcomes) > 0 {
 		attrs = append(attrs, fmt.Sprintf("Outcomes: %v", m.Outcomes))
 	}
+	if len(m.Traits) > 0 {
+		attrs = append(attrs, fmt.Sprintf("Traits: %v", m.Traits))
+	}
 
 	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
 }
@@ -105,7 +147,125 @@ func (o Outcome) String() string {
 	if o.Entities != nil {
 		attrs = append(attrs, fmt.Sprintf("Entities: %v", o.Entities))
 	}
-	attrs = append(attrs, fmt.Sprintf("Confidence: %.6f", o.Confidence))
+	if o.Intent != nil || o.Confidence != 0 { // omitted for empty outcomes
+		attrs = append(attrs, fmt.Sprintf("Confidence: %.6f", o.Confidence))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+// https://wit.ai/docs/http/20200513#get__language_link
+func (l LanguageDetection) String() string {
+	attrs := []string{}
+	if l.Error != nil {
+		attrs = append(attrs, fmt.Sprintf("Error: %s", *l.Error))
+	}
+	if l.Code != nil {
+		attrs = append(attrs, fmt.Sprintf("Code: %s", *l.Code))
+	}
+	if len(l.DetectedLocales) > 0 {
+		attrs = append(attrs, fmt.Sprintf("DetectedLocales: %v", l.DetectedLocales))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+func (d DetectedLocale) String() string {
+	attrs := []string{}
+	if d.Locale != nil {
+		attrs = append(attrs, fmt.Sprintf("Locale: %s", *d.Locale))
+	}
+	attrs = append(attrs, fmt.Sprintf("Confidence: %.6f", d.Confidence))
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+// https://wit.ai/docs/http/20200513#get__message_link
+func (m MessageV2) String() string {
+	attrs := []string{}
+	if m.Error != nil {
+		attrs = append(attrs, fmt.Sprintf("Error: %s", *m.Error))
+	}
+	if m.Code != nil {
+		attrs = append(attrs, fmt.Sprintf("Code: %s", *m.Code))
+	}
+	if m.MessageId != nil {
+		attrs = append(attrs, fmt.Sprintf("MessageId: %s", *m.MessageId))
+	}
+	if m.Text != nil {
+		attrs = append(attrs, fmt.Sprintf("Text: %s", *m.Text))
+	}
+	if len(m.Intents) > 0 {
+		attrs = append(attrs, fmt.Sprintf("Intents: %v", m.Intents))
+	}
+	if len(m.Entities) > 0 {
+		attrs = append(attrs, fmt.Sprintf("Entities: %v", m.Entities))
+	}
+	if len(m.Traits) > 0 {
+		attrs = append(attrs, fmt.Sprintf("Traits: %v", m.Traits))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+func (i DetectedIntent) String() string {
+	attrs := []string{}
+	if i.Id != nil {
+		attrs = append(attrs, fmt.Sprintf("Id: %s", *i.Id))
+	}
+	if i.Name != nil {
+		attrs = append(attrs, fmt.Sprintf("Name: %s", *i.Name))
+	}
+	attrs = append(attrs, fmt.Sprintf("Confidence: %.6f", i.Confidence))
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+func (e DetectedEntity) String() string {
+	attrs := []string{}
+	if e.Id != nil {
+		attrs = append(attrs, fmt.Sprintf("Id: %s", *e.Id))
+	}
+	if e.Name != nil {
+		attrs = append(attrs, fmt.Sprintf("Name: %s", *e.Name))
+	}
+	if e.Role != nil {
+		attrs = append(attrs, fmt.Sprintf("Role: %s", *e.Role))
+	}
+	attrs = append(attrs, fmt.Sprintf("Start: %d", e.Start))
+	attrs = append(attrs, fmt.Sprintf("End: %d", e.End))
+	if e.Body != nil {
+		attrs = append(attrs, fmt.Sprintf("Body: %s", *e.Body))
+	}
+	if e.Type != nil {
+		attrs = append(attrs, fmt.Sprintf("Type: %s", *e.Type))
+	}
+	if e.Value != nil {
+		attrs = append(attrs, fmt.Sprintf("Value: %v", e.Value))
+	}
+	if len(e.Entities) > 0 {
+		attrs = append(attrs, fmt.Sprintf("Entities: %v", e.Entities))
+	}
+	attrs = append(attrs, fmt.Sprintf("Confidence: %.6f", e.Confidence))
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+func (e EntityResolution) String() string {
+	attrs := []string{}
+	if e.Value != nil {
+		attrs = append(attrs, fmt.Sprintf("Value: %v", e.Value))
+	}
+	if e.Type != nil {
+		attrs = append(attrs, fmt.Sprintf("Type: %s", *e.Type))
+	}
+	if e.Unit != nil {
+		attrs = append(attrs, fmt.Sprintf("Unit: %s", *e.Unit))
+	}
+	if e.Role != nil {
+		attrs = append(attrs, fmt.Sprintf("Role: %s", *e.Role))
+	}
+	attrs = append(attrs, fmt.Sprintf("Confidence: %.6f", e.Confidence))
 
 	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
 }
@@ -122,6 +282,34 @@ func (i IntentExpression) String() string {
 	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
 }
 
+func (s Sample) String() string {
+	attrs := []string{}
+	if s.Text != nil {
+		attrs = append(attrs, fmt.Sprintf("Text: %s", *s.Text))
+	}
+	if s.Intent != nil {
+		attrs = append(attrs, fmt.Sprintf("Intent: %s", *s.Intent))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+func (r SampleResult) String() string {
+	attrs := []string{}
+	attrs = append(attrs, fmt.Sprintf("Sample: %s", r.Sample))
+	if r.Error != nil {
+		attrs = append(attrs, fmt.Sprintf("Error: %s", r.Error))
+	} else {
+		if r.Intent != nil {
+			attrs = append(attrs, fmt.Sprintf("Intent: %s", *r.Intent))
+		}
+		attrs = append(attrs, fmt.Sprintf("Confidence: %.6f", r.Confidence))
+	}
+	attrs = append(attrs, fmt.Sprintf("Passed: %t", r.Passed))
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
 func (i Intent) String() string {
 	attrs := []string{}
 	if i.Error != nil {
@@ -242,6 +430,19 @@ func (i IntentAttributes) String() string {
 	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
 }
 
+// https://wit.ai/docs/http/20200513#get__entities_link
+func (e EntitySummary) String() string {
+	attrs := []string{}
+	if e.Id != nil {
+		attrs = append(attrs, fmt.Sprintf("Id: %s", *e.Id))
+	}
+	if e.Name != nil {
+		attrs = append(attrs, fmt.Sprintf("Name: %s", *e.Name))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
 func (e Entity) String() string {
 	attrs := []string{}
 	if e.Error != nil {
@@ -250,6 +451,9 @@ func (e Entity) String() string {
 	if e.Code != nil {
 		attrs = append(attrs, fmt.Sprintf("Code: %s", *e.Code))
 	}
+	if e.MessageId != nil {
+		attrs = append(attrs, fmt.Sprintf("MessageId: %s", *e.MessageId))
+	}
 	if e.Id != nil {
 		attrs = append(attrs, fmt.Sprintf("Id: %s", *e.Id))
 	}
@@ -268,6 +472,15 @@ func (e Entity) String() string {
 	if len(e.Values) > 0 {
 		attrs = append(attrs, fmt.Sprintf("Values: %v", e.Values))
 	}
+	if len(e.Keywords) > 0 {
+		attrs = append(attrs, fmt.Sprintf("Keywords: %v", e.Keywords))
+	}
+	if len(e.Lookups) > 0 {
+		attrs = append(attrs, fmt.Sprintf("Lookups: %v", e.Lookups))
+	}
+	if len(e.Roles) > 0 {
+		attrs = append(attrs, fmt.Sprintf("Roles: %v", e.Roles))
+	}
 
 	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
 }
@@ -284,36 +497,1259 @@ func (e EntityValue) String() string {
 	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
 }
 
-// helper functions
+func (e *APIError) Error() string {
+	if len(e.Message) > 0 {
+		return fmt.Sprintf("api error (status: %d): %s", e.StatusCode, e.Message)
+	}
+	if e.HTML {
+		return fmt.Sprintf("api error (status: %d): %s: %s", e.StatusCode, ErrUnexpectedResponse, bodySnippet([]byte(e.Body)))
+	}
+	return fmt.Sprintf("api error (status: %d): %s", e.StatusCode, e.Body)
+}
 
-func (r ResponseError) HasError() bool { // XXX - due to inconsistency in response formats
-	if r.Error != nil || len(r.Errors) > 0 || r.Body != nil {
+// sentinel error for the error code (or status code) of this error, for errors.Is
+//
+// returns nil when there is no matching one
+func (e *APIError) Unwrap() error {
+	if err := codeError(e.Code); err != nil {
+		return err
+	}
+
+	switch e.StatusCode {
+	case 401:
+		return ErrUnauthorized
+	case 404:
+		return ErrNotFound
+	case 409:
+		return ErrAlreadyExists
+	case 400:
+		return ErrBadRequest
+	}
+
+	return nil
+}
+
+// errors.Is(err, ErrUnexpectedResponse) is also true when the response body was a html page
+// (in addition to the sentinel error from Unwrap)
+func (e *APIError) Is(target error) bool {
+	return target == ErrUnexpectedResponse && e.HTML
+}
+
+// sentinel error for given error code of wit.ai (nil when there is no matching one)
+func codeError(code string) error {
+	switch code {
+	case "auth", "no-auth":
+		return ErrUnauthorized
+	case "not-found":
+		return ErrNotFound
+	case "already-exists":
+		return ErrAlreadyExists
+	case "bad-request":
+		return ErrBadRequest
+	}
+	return nil
+}
+
+// check if given response (content type and body) looks like a html page (eg. from a proxy or a gateway)
+func looksLikeHTML(contentType string, body []byte) bool {
+	if strings.HasPrefix(contentType, "text/html") {
 		return true
 	}
-	return false
+	return bytes.HasPrefix(bytes.TrimSpace(body), []byte("<"))
 }
 
-func (r ResponseError) ErrorMessage() string { // XXX - due to inconsistency in response formats
-	errors := append([]string{}, r.Errors...)
-	if r.Error != nil {
-		errors = append(errors, *r.Error)
+// first part of given response body, in a single line
+func bodySnippet(body []byte) string {
+	const maxLength = 200
+
+	snippet := strings.Join(strings.Fields(string(body)), " ")
+	if len(snippet) > maxLength {
+		snippet = snippet[:maxLength] + "..."
 	}
-	if r.Body != nil {
-		errors = append(errors, *r.Body)
+	return snippet
+}
+
+// sentinel error for the error code of this response (nil when there is no matching one)
+func (r ResponseError) codeError() error {
+	if r.Code != nil {
+		return codeError(*r.Code)
 	}
-	return strings.Join(errors, ",")
+	return nil
 }
 
-func NewIntentExpression(body string) IntentExpression {
-	return IntentExpression{
-		Body: &body,
+// error of this response with given label (eg. "show entity"),
+// which wraps the sentinel error for its error code (eg. ErrNotFound) when there is a matching one
+func (r ResponseError) responseError(label string) error {
+	if codeErr := r.codeError(); codeErr != nil {
+		return fmt.Errorf("%s response error: %w (%s)", label, codeErr, r.ErrorMessage())
 	}
+	return fmt.Errorf("%s response error: %s", label, r.ErrorMessage())
 }
 
-func NewIntentExpressions(body ...string) []IntentExpression {
-	expressions := []IntentExpression{}
-	for _, b := range body {
-		expressions = append(expressions, NewIntentExpression(b))
+func (k Keyword) String() string {
+	attrs := []string{}
+	if k.Keyword != nil {
+		attrs = append(attrs, fmt.Sprintf("Keyword: %s", *k.Keyword))
+	}
+	if len(k.Synonyms) > 0 {
+		attrs = append(attrs, fmt.Sprintf("Synonyms: %v", k.Synonyms))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+// https://wit.ai/docs/http/20200513#post__utterances_link
+func (u Utterance) String() string {
+	attrs := []string{}
+	if u.Text != nil {
+		attrs = append(attrs, fmt.Sprintf("Text: %s", *u.Text))
+	}
+	if u.Intent != nil {
+		attrs = append(attrs, fmt.Sprintf("Intent: %s", *u.Intent))
+	}
+	if len(u.Entities) > 0 {
+		attrs = append(attrs, fmt.Sprintf("Entities: %v", u.Entities))
+	}
+	if len(u.Traits) > 0 {
+		attrs = append(attrs, fmt.Sprintf("Traits: %v", u.Traits))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+func (u UtteranceEntity) String() string {
+	attrs := []string{}
+	if u.Entity != nil {
+		attrs = append(attrs, fmt.Sprintf("Entity: %s", *u.Entity))
+	}
+	attrs = append(attrs, fmt.Sprintf("Start: %d", u.Start))
+	attrs = append(attrs, fmt.Sprintf("End: %d", u.End))
+	if u.Body != nil {
+		attrs = append(attrs, fmt.Sprintf("Body: %s", *u.Body))
+	}
+	if len(u.Entities) > 0 {
+		attrs = append(attrs, fmt.Sprintf("Entities: %v", u.Entities))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+func (u UtteranceTrait) String() string {
+	attrs := []string{}
+	if u.Trait != nil {
+		attrs = append(attrs, fmt.Sprintf("Trait: %s", *u.Trait))
+	}
+	if u.Value != nil {
+		attrs = append(attrs, fmt.Sprintf("Value: %s", *u.Value))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+func (u UtterancesResponse) String() string {
+	attrs := []string{}
+	if u.Error != nil {
+		attrs = append(attrs, fmt.Sprintf("Error: %s", *u.Error))
+	}
+	if u.Code != nil {
+		attrs = append(attrs, fmt.Sprintf("Code: %s", *u.Code))
+	}
+	attrs = append(attrs, fmt.Sprintf("Sent: %t", u.Sent))
+	attrs = append(attrs, fmt.Sprintf("N: %d", u.N))
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+// https://wit.ai/docs/http/20200513#get__traits__trait_link
+func (t Trait) String() string {
+	attrs := []string{}
+	if t.Error != nil {
+		attrs = append(attrs, fmt.Sprintf("Error: %s", *t.Error))
+	}
+	if t.Code != nil {
+		attrs = append(attrs, fmt.Sprintf("Code: %s", *t.Code))
+	}
+	if t.Id != nil {
+		attrs = append(attrs, fmt.Sprintf("Id: %s", *t.Id))
+	}
+	if t.Name != nil {
+		attrs = append(attrs, fmt.Sprintf("Name: %s", *t.Name))
+	}
+	if len(t.Values) > 0 {
+		attrs = append(attrs, fmt.Sprintf("Values: %v", t.Values))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+func (t TraitValue) String() string {
+	attrs := []string{}
+	if t.Id != nil {
+		attrs = append(attrs, fmt.Sprintf("Id: %s", *t.Id))
+	}
+	if t.Value != nil {
+		attrs = append(attrs, fmt.Sprintf("Value: %s", *t.Value))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+func (t DetectedTrait) String() string {
+	attrs := []string{}
+	if t.Id != nil {
+		attrs = append(attrs, fmt.Sprintf("Id: %s", *t.Id))
+	}
+	if t.Value != nil {
+		attrs = append(attrs, fmt.Sprintf("Value: %s", *t.Value))
+	}
+	attrs = append(attrs, fmt.Sprintf("Confidence: %.6f", t.Confidence))
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+func (e Export) String() string {
+	attrs := []string{}
+	if e.Error != nil {
+		attrs = append(attrs, fmt.Sprintf("Error: %s", *e.Error))
+	}
+	if e.Code != nil {
+		attrs = append(attrs, fmt.Sprintf("Code: %s", *e.Code))
+	}
+	if e.Uri != nil {
+		attrs = append(attrs, fmt.Sprintf("Uri: %s", *e.Uri))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+func (i ImportResult) String() string {
+	attrs := []string{}
+	if i.Error != nil {
+		attrs = append(attrs, fmt.Sprintf("Error: %s", *i.Error))
+	}
+	if i.Code != nil {
+		attrs = append(attrs, fmt.Sprintf("Code: %s", *i.Code))
+	}
+	if i.AppId != nil {
+		attrs = append(attrs, fmt.Sprintf("AppId: %s", *i.AppId))
+	}
+	if i.Version != nil {
+		attrs = append(attrs, fmt.Sprintf("Version: %s", *i.Version))
+	}
+	if i.Branch != nil {
+		attrs = append(attrs, fmt.Sprintf("Branch: %s", *i.Branch))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+// https://wit.ai/docs/http/20200513#get__apps__app_link
+func (a App) String() string {
+	attrs := []string{}
+	if a.Error != nil {
+		attrs = append(attrs, fmt.Sprintf("Error: %s", *a.Error))
+	}
+	if a.Code != nil {
+		attrs = append(attrs, fmt.Sprintf("Code: %s", *a.Code))
+	}
+	if a.Id != nil {
+		attrs = append(attrs, fmt.Sprintf("Id: %s", *a.Id))
+	}
+	if a.Name != nil {
+		attrs = append(attrs, fmt.Sprintf("Name: %s", *a.Name))
+	}
+	if a.Lang != nil {
+		attrs = append(attrs, fmt.Sprintf("Lang: %s", *a.Lang))
+	}
+	attrs = append(attrs, fmt.Sprintf("Private: %t", a.Private))
+	if a.CreatedAt != nil {
+		attrs = append(attrs, fmt.Sprintf("CreatedAt: %s", *a.CreatedAt))
+	}
+	if a.LastTraining != nil {
+		attrs = append(attrs, fmt.Sprintf("LastTraining: %s", *a.LastTraining))
+	}
+	if a.TrainingStatus != nil {
+		attrs = append(attrs, fmt.Sprintf("TrainingStatus: %s", *a.TrainingStatus))
+	}
+	if a.LastTrainingDurationSecs != nil {
+		attrs = append(attrs, fmt.Sprintf("LastTrainingDurationSecs: %d", *a.LastTrainingDurationSecs))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+func (a AppCreated) String() string {
+	attrs := []string{}
+	if a.Error != nil {
+		attrs = append(attrs, fmt.Sprintf("Error: %s", *a.Error))
+	}
+	if a.Code != nil {
+		attrs = append(attrs, fmt.Sprintf("Code: %s", *a.Code))
+	}
+	if a.AppId != nil {
+		attrs = append(attrs, fmt.Sprintf("AppId: %s", *a.AppId))
+	}
+	if a.AccessToken != nil {
+		attrs = append(attrs, fmt.Sprintf("AccessToken: %s", redacted)) // (not to be leaked in logs)
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+func (a AppUpdated) String() string {
+	attrs := []string{}
+	if a.Error != nil {
+		attrs = append(attrs, fmt.Sprintf("Error: %s", *a.Error))
+	}
+	if a.Code != nil {
+		attrs = append(attrs, fmt.Sprintf("Code: %s", *a.Code))
+	}
+	attrs = append(attrs, fmt.Sprintf("Success: %t", a.Success))
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+// https://wit.ai/docs/http/20200513#get__apps__app_tags_link
+func (t Tag) String() string {
+	attrs := []string{}
+	if t.Name != nil {
+		attrs = append(attrs, fmt.Sprintf("Name: %s", *t.Name))
+	}
+	if t.Desc != nil {
+		attrs = append(attrs, fmt.Sprintf("Desc: %s", *t.Desc))
+	}
+	if t.CreatedAt != nil {
+		attrs = append(attrs, fmt.Sprintf("CreatedAt: %s", *t.CreatedAt))
+	}
+	if t.UpdatedAt != nil {
+		attrs = append(attrs, fmt.Sprintf("UpdatedAt: %s", *t.UpdatedAt))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+// https://wit.ai/docs/http/20200513#post__apps__app_tags_link
+func (t TagCreated) String() string {
+	attrs := []string{}
+	if t.Error != nil {
+		attrs = append(attrs, fmt.Sprintf("Error: %s", *t.Error))
+	}
+	if t.Code != nil {
+		attrs = append(attrs, fmt.Sprintf("Code: %s", *t.Code))
+	}
+	if t.Tag != nil {
+		attrs = append(attrs, fmt.Sprintf("Tag: %s", *t.Tag))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+// https://wit.ai/docs/http/20200513#put__apps__app_tags__tag_link
+func (t TagMoved) String() string {
+	attrs := []string{}
+	if t.Error != nil {
+		attrs = append(attrs, fmt.Sprintf("Error: %s", *t.Error))
+	}
+	if t.Code != nil {
+		attrs = append(attrs, fmt.Sprintf("Code: %s", *t.Code))
+	}
+	if t.Tag != nil {
+		attrs = append(attrs, fmt.Sprintf("Tag: %s", *t.Tag))
+	}
+	if t.MovedTo != nil {
+		attrs = append(attrs, fmt.Sprintf("MovedTo: %s", *t.MovedTo))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+// https://wit.ai/docs/http/20240304#post__dictation_link
+func (d DictationResult) String() string {
+	attrs := []string{}
+	if d.Text != nil {
+		attrs = append(attrs, fmt.Sprintf("Text: %s", *d.Text))
+	}
+	if len(d.Chunks) > 0 {
+		attrs = append(attrs, fmt.Sprintf("Chunks: %v", d.Chunks))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+func (d DictationChunk) String() string {
+	attrs := []string{}
+	if d.Error != nil {
+		attrs = append(attrs, fmt.Sprintf("Error: %s", *d.Error))
+	}
+	if d.Code != nil {
+		attrs = append(attrs, fmt.Sprintf("Code: %s", *d.Code))
+	}
+	if d.Text != nil {
+		attrs = append(attrs, fmt.Sprintf("Text: %s", *d.Text))
+	}
+	if d.Speech != nil {
+		attrs = append(attrs, fmt.Sprintf("Speech: %v", *d.Speech))
+	}
+	attrs = append(attrs, fmt.Sprintf("IsFinal: %t", d.IsFinal))
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+func (d DictationSpeech) String() string {
+	attrs := []string{}
+	attrs = append(attrs, fmt.Sprintf("Confidence: %.6f", d.Confidence))
+	if len(d.Tokens) > 0 {
+		attrs = append(attrs, fmt.Sprintf("Tokens: %v", d.Tokens))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+func (t SpeechToken) String() string {
+	attrs := []string{}
+	if t.Token != nil {
+		attrs = append(attrs, fmt.Sprintf("Token: %s", *t.Token))
+	}
+	attrs = append(attrs, fmt.Sprintf("Start: %d", t.Start))
+	attrs = append(attrs, fmt.Sprintf("End: %d", t.End))
+	attrs = append(attrs, fmt.Sprintf("Confidence: %.6f", t.Confidence))
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+// https://wit.ai/docs/http/20240304#get__voices_link
+func (v Voice) String() string {
+	attrs := []string{}
+	if v.Name != nil {
+		attrs = append(attrs, fmt.Sprintf("Name: %s", *v.Name))
+	}
+	if v.Locale != nil {
+		attrs = append(attrs, fmt.Sprintf("Locale: %s", *v.Locale))
+	}
+	if v.Gender != nil {
+		attrs = append(attrs, fmt.Sprintf("Gender: %s", *v.Gender))
+	}
+	if len(v.Styles) > 0 {
+		attrs = append(attrs, fmt.Sprintf("Styles: %v", v.Styles))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+func (d DeletedResponse) String() string {
+	attrs := []string{}
+	if d.Error != nil {
+		attrs = append(attrs, fmt.Sprintf("Error: %s", *d.Error))
+	}
+	if d.Code != nil {
+		attrs = append(attrs, fmt.Sprintf("Code: %s", *d.Code))
+	}
+	if d.Deleted != nil {
+		attrs = append(attrs, fmt.Sprintf("Deleted: %s", *d.Deleted))
+	}
+	attrs = append(attrs, fmt.Sprintf("Success: %t", d.Success))
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+// XXX - `deleted` can be a string or a richer object, depending on the versions
+func (d *DeletedResponse) UnmarshalJSON(data []byte) error {
+	var res struct {
+		ResponseError
+
+		Deleted interface{} `json:"deleted"`
+		Success bool        `json:"success"`
+	}
+	if err := json.Unmarshal(data, &res); err != nil {
+		return err
+	}
+
+	d.ResponseError = res.ResponseError
+	d.Success = res.Success
+	d.Raw = string(data)
+
+	switch deleted := res.Deleted.(type) {
+	case nil:
+		d.Deleted = nil
+	case string:
+		d.Deleted = &deleted
+	default:
+		if encoded, err := json.Marshal(deleted); err == nil {
+			d.Deleted = String(string(encoded))
+		}
+	}
+
+	return nil
+}
+
+// XXX - older versions respond with names only
+func (e *EntitySummary) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		e.Id, e.Name = String(name), String(name)
+		return nil
+	}
+
+	var res struct {
+		Id   *string `json:"id"`
+		Name *string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &res); err != nil {
+		return err
+	}
+
+	e.Id, e.Name = res.Id, res.Name
+
+	return nil
+}
+
+// merged values are sent as keys of the context (known fields take precedence)
+func (c ConverseContext) MarshalJSON() ([]byte, error) {
+	type known ConverseContext
+
+	var fields map[string]interface{}
+	if data, err := json.Marshal(known(c)); err == nil {
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	merged := map[string]interface{}{}
+	for key, value := range c.Values {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+
+	return json.Marshal(merged)
+}
+
+// values other than known fields are kept in Values
+func (c *ConverseContext) UnmarshalJSON(data []byte) error {
+	type known ConverseContext
+
+	var k known
+	if err := json.Unmarshal(data, &k); err != nil {
+		return err
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	for _, key := range []string{"state", "reference_time", "timezone", "location"} {
+		delete(values, key)
+	}
+
+	*c = ConverseContext(k)
+	if len(values) > 0 {
+		c.Values = values
+	}
+
+	return nil
+}
+
+// original payload is kept in RawJSON
+func (m *Message) UnmarshalJSON(data []byte) error {
+	type message Message
+
+	var res message
+	if err := json.Unmarshal(data, &res); err != nil {
+		return err
+	}
+
+	*m = Message(res)
+	m.RawJSON = append(json.RawMessage{}, data...)
+
+	return nil
+}
+
+// original payload is kept in RawJSON
+func (m *MessageV2) UnmarshalJSON(data []byte) error {
+	type messageV2 MessageV2
+
+	var res messageV2
+	if err := json.Unmarshal(data, &res); err != nil {
+		return err
+	}
+
+	*m = MessageV2(res)
+	m.RawJSON = append(json.RawMessage{}, data...)
+
+	return nil
+}
+
+// original payload is kept in RawJSON
+func (c *Converse) UnmarshalJSON(data []byte) error {
+	type converse Converse
+
+	var res converse
+	if err := json.Unmarshal(data, &res); err != nil {
+		return err
+	}
+
+	*c = Converse(res)
+	c.RawJSON = append(json.RawMessage{}, data...)
+
+	return nil
+}
+
+// original payload is kept in RawJSON
+func (e *Entity) UnmarshalJSON(data []byte) error {
+	type entity Entity
+
+	var res entity
+	if err := json.Unmarshal(data, &res); err != nil {
+		return err
+	}
+
+	*e = Entity(res)
+	e.RawJSON = append(json.RawMessage{}, data...)
+
+	return nil
+}
+
+func (r RateLimit) String() string {
+	attrs := []string{}
+	attrs = append(attrs, fmt.Sprintf("Limit: %d", r.Limit))
+	attrs = append(attrs, fmt.Sprintf("Remaining: %d", r.Remaining))
+	if !r.Reset.IsZero() {
+		attrs = append(attrs, fmt.Sprintf("Reset: %s", r.Reset))
+	}
+	attrs = append(attrs, fmt.Sprintf("UpdatedAt: %s", r.UpdatedAt))
+
+	return fmt.Sprintf("{%s}", strings.Join(attrs, ", "))
+}
+
+// helper functions
+
+// send given request by calling the function
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// check if this converse step ends the conversation
+//
+// a step without type (eg. on errors) is also treated as 'stop'
+func (c Converse) IsStop() bool {
+	return c.Type == nil || *c.Type == "stop"
+}
+
+// split given stream of json objects into each object
+func splitJSONStream(data []byte) (objects []json.RawMessage, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for decoder.More() {
+		var object json.RawMessage
+		if err = decoder.Decode(&object); err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+// backoff duration before the next attempt (not greater than MaxRetryBackoff)
+func (r *RetryConfig) backoff(attempt int) time.Duration {
+	backoff := r.Backoff
+	for i := 1; i < attempt && backoff > 0 && backoff < MaxRetryBackoff; i++ { // (doubled without overflowing)
+		backoff *= 2
+	}
+	if backoff > MaxRetryBackoff {
+		backoff = MaxRetryBackoff
+	}
+	if r.Jitter && backoff > 0 {
+		backoff = time.Duration(rand.Int63n(int64(backoff) + 1))
+	}
+	return backoff
+}
+
+// build an APIError from given status code and response body
+func newAPIError(statusCode int, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: statusCode,
+		Body:       string(body),
+		HTML:       looksLikeHTML("", body),
+	}
+
+	var resErr ResponseError
+	if err := json.Unmarshal(body, &resErr); err == nil {
+		apiErr.Message = resErr.ErrorMessage()
+		if resErr.Code != nil {
+			apiErr.Code = *resErr.Code
+		}
+	}
+
+	return apiErr
+}
+
+func (r ResponseError) HasError() bool { // XXX - due to inconsistency in response formats
+	if r.Error != nil || len(r.Errors) > 0 || r.Body != nil {
+		return true
+	}
+	return false
+}
+
+func (r ResponseError) ErrorMessage() string { // XXX - due to inconsistency in response formats
+	errors := append([]string{}, r.Errors...)
+	if r.Error != nil {
+		errors = append(errors, *r.Error)
+	}
+	if r.Body != nil {
+		errors = append(errors, *r.Body)
+	}
+	return strings.Join(errors, ",")
+}
+
+// id of the message (empty when not given)
+func (m Message) MessageID() string {
+	if m.MessageId != nil {
+		return *m.MessageId
+	}
+	return ""
+}
+
+// id of the message (empty when not given)
+func (m MessageV2) MessageID() string {
+	if m.MessageId != nil {
+		return *m.MessageId
+	}
+	return ""
+}
+
+// id of the message (empty when not given)
+func (c Converse) MessageID() string {
+	if c.MessageId != nil {
+		return *c.MessageId
+	}
+	return ""
+}
+
+// id of the message (empty when not given)
+func (e Entity) MessageID() string {
+	if e.MessageId != nil {
+		return *e.MessageId
+	}
+	return ""
+}
+
+// outcome with the highest confidence
+func (m Message) BestOutcome() (*Outcome, bool) {
+	var best *Outcome
+	for i := range m.Outcomes {
+		if best == nil || m.Outcomes[i].Confidence > best.Confidence {
+			best = &m.Outcomes[i]
+		}
+	}
+	return best, best != nil
+}
+
+// outcome with the highest confidence, only when its confidence is >= threshold
+func (m Message) BestOutcomeAbove(threshold float32) (*Outcome, bool) {
+	if best, exists := m.BestOutcome(); exists && best.Confidence >= threshold {
+		return best, true
+	}
+	return nil, false
+}
+
+// copy of outcomes, sorted by confidence in descending order (stable for outcomes of the same confidence)
+func (m Message) OutcomesByConfidence() []Outcome {
+	outcomes := append([]Outcome{}, m.Outcomes...)
+	sort.SliceStable(outcomes, func(i, j int) bool {
+		return outcomes[i].Confidence > outcomes[j].Confidence
+	})
+	return outcomes
+}
+
+// copy of intents, sorted by confidence in descending order (stable for intents of the same confidence)
+func (m MessageV2) IntentsByConfidence() []DetectedIntent {
+	intents := append([]DetectedIntent{}, m.Intents...)
+	sort.SliceStable(intents, func(i, j int) bool {
+		return intents[i].Confidence > intents[j].Confidence
+	})
+	return intents
+}
+
+// intent with the highest confidence
+func (m MessageV2) BestIntent() (*DetectedIntent, bool) {
+	var best *DetectedIntent
+	for i := range m.Intents {
+		if best == nil || m.Intents[i].Confidence > best.Confidence {
+			best = &m.Intents[i]
+		}
+	}
+	return best, best != nil
+}
+
+// intent with the highest confidence, only when its confidence is >= threshold
+func (m MessageV2) BestIntentAbove(threshold float32) (*DetectedIntent, bool) {
+	if best, exists := m.BestIntent(); exists && best.Confidence >= threshold {
+		return best, true
+	}
+	return nil, false
+}
+
+// copy of the message without intents, entities, and traits of confidence below threshold
+//
+// RawJSON is cleared, for it does not match the filtered fields anymore
+func (m MessageV2) filterByConfidence(threshold float32) MessageV2 {
+	filtered := m
+	filtered.RawJSON = nil
+
+	filtered.Intents = []DetectedIntent{}
+	for _, intent := range m.Intents {
+		if intent.Confidence >= threshold {
+			filtered.Intents = append(filtered.Intents, intent)
+		}
+	}
+
+	filtered.Entities = map[string][]DetectedEntity{}
+	for key, entities := range m.Entities {
+		for _, entity := range entities {
+			if entity.Confidence >= threshold {
+				filtered.Entities[key] = append(filtered.Entities[key], entity)
+			}
+		}
+	}
+
+	filtered.Traits = map[string][]DetectedTrait{}
+	for key, traits := range m.Traits {
+		for _, trait := range traits {
+			if trait.Confidence >= threshold {
+				filtered.Traits[key] = append(filtered.Traits[key], trait)
+			}
+		}
+	}
+
+	return filtered
+}
+
+// decode values of the entity with given name into typed structs
+func (o Outcome) EntityValues(name string) []EntityResolution {
+	return resolveEntities(o.Entities, name)
+}
+
+// first value of the entity with given name, as a string
+func (o Outcome) FirstEntityValue(name string) (string, bool) {
+	if values := o.EntityValues(name); len(values) > 0 && values[0].Value != nil {
+		if str, ok := values[0].Value.(string); ok {
+			return str, true
+		}
+		return fmt.Sprintf("%v", values[0].Value), true
+	}
+	return "", false
+}
+
+// decode values of the entity with given name and role into typed structs
+func (o Outcome) EntityValuesWithRole(name, role string) []EntityResolution {
+	values := []EntityResolution{}
+	for _, value := range o.EntityValues(name) {
+		if value.Role != nil && *value.Role == role {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// decode values of the entity with given name into typed structs (same as Outcome.EntityValues)
+func (c Converse) EntityValues(name string) []EntityResolution {
+	return resolveEntities(c.Entities, name)
+}
+
+// decode values of the entity with given name and role into typed structs
+func (c Converse) EntityValuesWithRole(name, role string) []EntityResolution {
+	values := []EntityResolution{}
+	for _, value := range c.EntityValues(name) {
+		if value.Role != nil && *value.Role == role {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// builtin entities (eg. "wit/datetime") of the outcome
+func (o Outcome) BuiltinEntities() map[string]interface{} {
+	entities := map[string]interface{}{}
+	for name, value := range o.Entities {
+		if IsBuiltin(name) {
+			entities[name] = value
+		}
+	}
+	return entities
+}
+
+// custom (non-builtin) entities of the outcome
+func (o Outcome) CustomEntities() map[string]interface{} {
+	entities := map[string]interface{}{}
+	for name, value := range o.Entities {
+		if !IsBuiltin(name) {
+			entities[name] = value
+		}
+	}
+	return entities
+}
+
+// check if given entity name is of a builtin entity ("wit/..." or "wit$...")
+func IsBuiltin(name string) bool {
+	return strings.HasPrefix(name, "wit/") || strings.HasPrefix(name, "wit$")
+}
+
+// detected entities with given entity name and role (eg. "wit$datetime", "departure")
+func (m MessageV2) EntitiesWithRole(name, role string) []DetectedEntity {
+	entities := []DetectedEntity{}
+	for _, entity := range m.Entities[fmt.Sprintf("%s:%s", name, role)] {
+		if entity.Role == nil || *entity.Role == role {
+			entities = append(entities, entity)
+		}
+	}
+	return entities
+}
+
+// decode the raw entities map's values with given name
+//
+// returns nil when there is no such entity, or its values cannot be decoded
+func resolveEntities(entities map[string]interface{}, name string) []EntityResolution {
+	raw, exists := entities[name]
+	if !exists || raw == nil {
+		return nil
+	}
+
+	var data []byte
+	var err error
+	if data, err = json.Marshal(raw); err != nil {
+		return nil
+	}
+
+	resolutions := []EntityResolution{}
+	if _, isArray := raw.([]interface{}); isArray {
+		if err = json.Unmarshal(data, &resolutions); err != nil {
+			return nil
+		}
+	} else {
+		var resolution EntityResolution
+		if err = json.Unmarshal(data, &resolution); err != nil {
+			return nil
+		}
+		resolutions = append(resolutions, resolution)
+	}
+
+	return resolutions
+}
+
+// layout of reference time: ISO-8601 with numeric timezone offset,
+// eg. "2016-05-17T14:30:00+09:00" (wit.ai does not accept "Z" for UTC)
+const ReferenceTimeLayout = "2006-01-02T15:04:05-07:00"
+
+// new empty context
+func NewContext() *Context {
+	return &Context{}
+}
+
+// new context for converse steps
+func NewConverseContext() *ConverseContext {
+	return &ConverseContext{
+		Values: map[string]interface{}{},
+	}
+}
+
+// merge a value (eg. of an entity on 'merge' steps) with given key into context
+func (c *ConverseContext) Merge(key string, value interface{}) *ConverseContext {
+	if c.Values == nil {
+		c.Values = map[string]interface{}{}
+	}
+	c.Values[key] = value
+	return c
+}
+
+// remove a merged value with given key from context
+func (c *ConverseContext) Remove(key string) *ConverseContext {
+	delete(c.Values, key)
+	return c
+}
+
+// set state flags of context
+func (c *ConverseContext) WithState(flags ...string) *ConverseContext {
+	c.State = flags
+	return c
+}
+
+// set timezone (eg. "Asia/Seoul") of context
+func (c *Context) WithTimeZone(tz string) *Context {
+	c.TimeZone = &tz
+	return c
+}
+
+// set reference time of context
+func (c *Context) WithReferenceTime(t time.Time) *Context {
+	c.SetReferenceTime(t)
+	return c
+}
+
+// set reference time of context, formatted with ReferenceTimeLayout
+func (c *Context) SetReferenceTime(t time.Time) {
+	referenceTime := t.Format(ReferenceTimeLayout)
+	c.ReferenceTime = &referenceTime
+}
+
+// get reference time of context as time.Time
+func (c Context) GetReferenceTime() (t time.Time, err error) {
+	if c.ReferenceTime == nil {
+		return t, fmt.Errorf("no reference time in context")
+	}
+	return ParseReferenceTime(*c.ReferenceTime)
+}
+
+// parse given string formatted with ReferenceTimeLayout
+func ParseReferenceTime(referenceTime string) (time.Time, error) {
+	return time.Parse(ReferenceTimeLayout, referenceTime)
+}
+
+// value of a builtin datetime entity ("wit/datetime" or "wit$datetime")
+//
+// https://wit.ai/docs/built-in-entities/20200513#wit_datetime
+type datetimeEntity struct {
+	Type  *string     `json:"type"` // "value" or "interval"
+	Value interface{} `json:"value,omitempty"`
+	From  interface{} `json:"from,omitempty"` // string, or object with "value" and "grain"
+	To    interface{} `json:"to,omitempty"`   // string, or object with "value" and "grain"
+}
+
+// decode given raw datetime entity (eg. a value of Outcome.Entities, an EntityResolution, or a DetectedEntity)
+//
+// the first one is used when raw is an array of them
+func decodeDatetimeEntity(raw interface{}) (entity datetimeEntity, err error) {
+	if values, isArray := raw.([]interface{}); isArray {
+		if len(values) == 0 {
+			return entity, fmt.Errorf("no datetime value in entity")
+		}
+		raw = values[0]
+	}
+
+	var data []byte
+	if data, err = json.Marshal(raw); err == nil {
+		err = json.Unmarshal(data, &entity)
+	}
+	if err != nil {
+		return entity, fmt.Errorf("invalid datetime entity: %w", err)
+	}
+	return entity, nil
+}
+
+// parse given datetime (string, or object with "value") in ISO-8601 format
+//
+// returns nil when it is not given
+func parseDatetime(v interface{}) (*time.Time, error) {
+	if object, ok := v.(map[string]interface{}); ok {
+		v = object["value"]
+	}
+
+	switch value := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid datetime value: '%s' (%s)", value, err)
+		}
+		return &t, nil
+	}
+
+	return nil, fmt.Errorf("invalid datetime value: %v", v)
+}
+
+// parse given raw "wit/datetime" entity of "value" type as time.Time
+//
+// returns false (without error) when it is of "interval" type (see ParseDatetimeInterval)
+func ParseDatetimeEntity(raw interface{}) (t time.Time, exists bool, err error) {
+	var entity datetimeEntity
+	if entity, err = decodeDatetimeEntity(raw); err != nil {
+		return t, false, err
+	}
+	if entity.Type != nil && *entity.Type == "interval" {
+		return t, false, nil
+	}
+
+	var parsed *time.Time
+	if parsed, err = parseDatetime(entity.Value); err != nil || parsed == nil {
+		return t, false, err
+	}
+	return *parsed, true, nil
+}
+
+// parse given raw "wit/datetime" entity of "interval" type as times of its both ends
+//
+// an open end (eg. of "after 5pm") is returned as nil,
+// and both are nil (without error) when it is not of "interval" type;
+// returns an error when an interval has no ends at all
+func ParseDatetimeInterval(raw interface{}) (from, to *time.Time, err error) {
+	var entity datetimeEntity
+	if entity, err = decodeDatetimeEntity(raw); err != nil {
+		return nil, nil, err
+	}
+	if entity.Type == nil || *entity.Type != "interval" {
+		return nil, nil, nil
+	}
+
+	if from, err = parseDatetime(entity.From); err != nil {
+		return nil, nil, err
+	}
+	if to, err = parseDatetime(entity.To); err != nil {
+		return nil, nil, err
+	}
+	if from == nil && to == nil {
+		return nil, nil, fmt.Errorf("no ends in datetime interval")
+	}
+	return from, to, nil
+}
+
+// set location of context
+func (c *Context) WithLocation(lat, lon float32) *Context {
+	c.Location = &Location{
+		Latitude:  lat,
+		Longitude: lon,
+	}
+	return c
+}
+
+// set location of context, after validating its ranges of latitude (-90 ~ 90) and longitude (-180 ~ 180)
+func (c *Context) SetLocation(lat, lon float32) error {
+	if !(lat >= -90 && lat <= 90) { // (also false for NaN)
+		return fmt.Errorf("invalid latitude: %f (should be between -90 and 90)", lat)
+	}
+	if !(lon >= -180 && lon <= 180) {
+		return fmt.Errorf("invalid longitude: %f (should be between -180 and 180)", lon)
+	}
+
+	c.WithLocation(lat, lon)
+
+	return nil
+}
+
+// set state of context
+func (c *Context) WithState(state interface{}) *Context {
+	c.State = state
+	return c
+}
+
+func NewIntentExpression(body string) IntentExpression {
+	return IntentExpression{
+		Body: &body,
+	}
+}
+
+func NewIntentExpressions(body ...string) []IntentExpression {
+	expressions := []IntentExpression{}
+	for _, b := range body {
+		expressions = append(expressions, NewIntentExpression(b))
+	}
+	return expressions
+}
+
+// pointer of given string (for filling *string fields)
+func String(s string) *string {
+	return &s
+}
+
+// new entity value with given value and expressions
+func NewEntityValue(value string, expressions ...string) EntityValue {
+	return EntityValue{
+		Expressions: append([]string{}, expressions...),
+		Value:       &value,
+	}
+}
+
+// new entity with given name (also used as its id)
+func NewEntity(name string) Entity {
+	return Entity{
+		Id:   String(name),
+		Name: String(name),
+	}
+}
+
+// format converse steps as a readable transcript
+func FormatTranscript(steps []Converse) string {
+	return FormatTranscriptWithQuery("", steps)
+}
+
+// format converse steps as a readable transcript, prefixed with the user's query
+func FormatTranscriptWithQuery(query string, steps []Converse) string {
+	lines := []string{}
+	if len(query) > 0 {
+		lines = append(lines, fmt.Sprintf("> user: %s", query))
+	}
+	for i, step := range steps {
+		var line string
+		if step.HasError() {
+			line = fmt.Sprintf("error: %s", step.ErrorMessage())
+		} else if step.Type == nil {
+			line = "(no type)"
+		} else {
+			switch *step.Type {
+			case "msg":
+				if step.Message != nil {
+					line = fmt.Sprintf("bot: %s", *step.Message)
+				} else {
+					line = "bot: (empty message)"
+				}
+			case "action":
+				if step.Action != nil {
+					line = fmt.Sprintf("action: %s", *step.Action)
+				} else {
+					line = "action: (unnamed)"
+				}
+			case "merge":
+				line = fmt.Sprintf("merge: %v", step.Entities)
+			case "stop":
+				line = "stop"
+			default:
+				line = fmt.Sprintf("%s: %s", *step.Type, step)
+			}
+		}
+		if step.hasConfidence() {
+			line = fmt.Sprintf("%s (confidence: %.6f)", line, step.Confidence)
+		}
+		lines = append(lines, fmt.Sprintf("%d. %s", i+1, line))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// new utterance with given text and intent (can be empty)
+func NewUtterance(text, intent string, entities []UtteranceEntity, traits []UtteranceTrait) Utterance {
+	utterance := Utterance{
+		Text:     &text,
+		Entities: append([]UtteranceEntity{}, entities...),
+		Traits:   append([]UtteranceTrait{}, traits...),
+	}
+	if len(intent) > 0 {
+		utterance.Intent = &intent
+	}
+	return utterance
+}
+
+// new utterance entity with given entity name, role, and span of body
+//
+// role defaults to the entity name when empty
+func NewUtteranceEntity(name, role string, start, end int, body string) UtteranceEntity {
+	if len(role) <= 0 {
+		role = name
+	}
+	entity := fmt.Sprintf("%s:%s", name, role)
+	return UtteranceEntity{
+		Entity:   &entity,
+		Start:    start,
+		End:      end,
+		Body:     &body,
+		Entities: []UtteranceEntity{},
+	}
+}
+
+// new utterance trait with given trait name and value
+func NewUtteranceTrait(trait, value string) UtteranceTrait {
+	return UtteranceTrait{
+		Trait: &trait,
+		Value: &value,
 	}
-	return expressions
 }
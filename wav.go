@@ -0,0 +1,96 @@
+// wav.go: validation of wav headers before uploading
+
+package witai
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// wav audio formats
+const (
+	wavFormatPCM = 1
+)
+
+// supported ranges of wav format
+const (
+	minWavSampleRate = 8000
+	maxWavSampleRate = 48000
+	maxWavChannels   = 2
+)
+
+// format of a wav file, read from its header
+type WavFormat struct {
+	AudioFormat   uint16 // 1 = PCM
+	Channels      uint16
+	SampleRate    uint32
+	BitsPerSample uint16
+}
+
+// read the header of wav data from given reader
+func ParseWavHeader(reader io.Reader) (format WavFormat, err error) {
+	var riff [12]byte
+	if _, err = io.ReadFull(reader, riff[:]); err != nil {
+		return format, fmt.Errorf("%w: failed to read RIFF header (%s)", ErrUnsupportedWav, err)
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return format, fmt.Errorf("%w: not a RIFF/WAVE file", ErrUnsupportedWav)
+	}
+
+	// find 'fmt ' chunk
+	for {
+		var header [8]byte
+		if _, err = io.ReadFull(reader, header[:]); err != nil {
+			return format, fmt.Errorf("%w: no 'fmt ' chunk (%s)", ErrUnsupportedWav, err)
+		}
+		size := binary.LittleEndian.Uint32(header[4:8])
+
+		if string(header[0:4]) == "fmt " {
+			if size < 16 {
+				return format, fmt.Errorf("%w: 'fmt ' chunk is too short (%d bytes)", ErrUnsupportedWav, size)
+			}
+
+			var chunk [16]byte
+			if _, err = io.ReadFull(reader, chunk[:]); err != nil {
+				return format, fmt.Errorf("%w: failed to read 'fmt ' chunk (%s)", ErrUnsupportedWav, err)
+			}
+
+			format.AudioFormat = binary.LittleEndian.Uint16(chunk[0:2])
+			format.Channels = binary.LittleEndian.Uint16(chunk[2:4])
+			format.SampleRate = binary.LittleEndian.Uint32(chunk[4:8])
+			format.BitsPerSample = binary.LittleEndian.Uint16(chunk[14:16])
+
+			return format, nil
+		}
+
+		// skip other chunks (padded to even sizes)
+		if _, err = io.CopyN(io.Discard, reader, int64(size+size%2)); err != nil {
+			return format, fmt.Errorf("%w: failed to skip '%s' chunk (%s)", ErrUnsupportedWav, string(header[0:4]), err)
+		}
+	}
+}
+
+// read the header of wav data from given reader, and check if its format is supported
+func ValidateWav(reader io.Reader) (format WavFormat, err error) {
+	if format, err = ParseWavHeader(reader); err != nil {
+		return format, err
+	}
+
+	if format.AudioFormat != wavFormatPCM {
+		return format, fmt.Errorf("%w: audio format %d is not PCM", ErrUnsupportedWav, format.AudioFormat)
+	}
+	if format.Channels == 0 || format.Channels > maxWavChannels {
+		return format, fmt.Errorf("%w: %d channels", ErrUnsupportedWav, format.Channels)
+	}
+	if format.SampleRate < minWavSampleRate || format.SampleRate > maxWavSampleRate {
+		return format, fmt.Errorf("%w: sample rate %dHz", ErrUnsupportedWav, format.SampleRate)
+	}
+	switch format.BitsPerSample {
+	case 8, 16, 24, 32:
+	default:
+		return format, fmt.Errorf("%w: %d bits per sample", ErrUnsupportedWav, format.BitsPerSample)
+	}
+
+	return format, nil
+}
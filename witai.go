@@ -4,505 +4,3335 @@ package witai
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
+	"mime"
 	"net/http"
 	"net/url"
+	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 const (
-	DefaultVersion = "20160516" // last update: 2016.05.17.
+	DefaultVersion = "20240304" // last update: 2026.10.14.
+	LegacyVersion  = "20160516" // for the methods which send requests or return responses in the legacy format (eg. Message with outcomes, entity values, converse, and intents)
+	DefaultBaseURL = "https://api.wit.ai"
+
+	LibraryVersion   = "0.1.0"
+	DefaultUserAgent = "wit.ai-go/" + LibraryVersion
+
+	MaxUtterancesLimit = 10000 // max number of utterances in a page
+	MaxAppsLimit       = 10000 // max number of apps in a page
+	MaxEntitiesLimit   = 10000 // max number of entities in a page
+	MaxKeywordsLimit   = 10000 // max number of keywords in a page
+
+	DefaultConverseMaxSteps = 10
+
+	DefaultEvaluateConcurrency = 4 // number of concurrent queries in EvaluateSamples
+
+	OmitN = -1 // n for speech queries, to omit the parameter and use the server's default
+
+	redacted = "***"
+
+	minCompressSize = 1024 // request bodies smaller than this are not compressed
+
+	DefaultMaxResponseBytes = 8 * 1024 * 1024 // 8MB
+
+	MaxRetryBackoff = 5 * time.Minute // upper limit of backoff durations between retries
+)
+
+// content types for speech
+const (
+	ContentTypeMp3  = "audio/mpeg3"
+	ContentTypeWav  = "audio/wav"
+	ContentTypeRaw  = "audio/raw" // needs encoding, bits, rate, and endian parameters
+	ContentTypeUlaw = "audio/ulaw"
+	ContentTypeOgg  = "audio/ogg"
+)
+
+// content types of synthesized speech
+const (
+	SynthesizeTypeMp3   = "audio/mpeg"
+	SynthesizeTypePcm16 = "audio/pcm16" // raw 16-bit signed integer, little endian, mono
+	SynthesizeTypeWav   = "audio/wav"
+
+	DefaultSynthesizeType = SynthesizeTypeMp3
 )
 
+// audio media types supported by wit.ai
+var supportedAudioTypes = map[string]bool{
+	ContentTypeMp3:  true,
+	ContentTypeWav:  true,
+	ContentTypeRaw:  true,
+	ContentTypeUlaw: true,
+	ContentTypeOgg:  true,
+}
+
+// training status of apps
+const (
+	TrainingStatusDone      = "done"
+	TrainingStatusScheduled = "scheduled"
+	TrainingStatusOngoing   = "ongoing"
+
+	DefaultTrainingPollInterval = 5 * time.Second
+)
+
+// lookup strategies of entities
+const (
+	LookupFreeText = "free-text"
+	LookupKeywords = "keywords"
+)
+
+// errors
+var (
+	ErrUnauthorized     = errors.New("unauthorized")
+	ErrNotFound         = errors.New("not found")
+	ErrAlreadyExists    = errors.New("already exists")
+	ErrBadRequest       = errors.New("bad request")
+	ErrConverseMaxSteps = errors.New("converse max steps exceeded")
+	ErrTrainingFailed   = errors.New("training failed")
+
+	ErrInvalidContentType = errors.New("invalid content type")
+	ErrResponseTooLarge   = errors.New("response body exceeded the limit")
+	ErrUnexpectedResponse = errors.New("unexpected non-json response")
+	ErrUnexpectedRedirect = errors.New("unexpected redirect")
+	ErrUnsupportedWav     = errors.New("unsupported wav format")
+)
+
+// API versions which are still supported by wit.ai (in ascending order)
+var SupportedVersions = []string{
+	"20200513",
+	"20210928",
+	"20220622",
+	"20230215",
+	"20240304",
+}
+
+// layout of API version
+const versionLayout = "20060102"
+
 // new client with default version
 func NewClient(token string) *Client {
-	version := DefaultVersion
-	return NewClientWithVersion(token, version)
+	return NewClientWithOptions(token)
 }
 
 // new client with other version
+//
+// panics if given version is not in YYYYMMDD format
 func NewClientWithVersion(token, version string) *Client {
+	return NewClientWithOptions(token, WithVersion(version))
+}
+
+// new client with given options
+//
+// panics if the version given with WithVersion is not in YYYYMMDD format
+func NewClientWithOptions(token string, opts ...Option) *Client {
+	version := DefaultVersion
 	headerAuth := fmt.Sprintf("Bearer %s", token)
 	headerAccept := fmt.Sprintf("application/vnd.wit.%s+json", version)
 
-	return &Client{
+	c := &Client{
 		Token:        &token,
 		Version:      &version,
+		BaseURL:      DefaultBaseURL,
+		UserAgent:    DefaultUserAgent,
+		Logger:       log.Default(),
 		headerAuth:   &headerAuth,
 		headerAccept: &headerAccept,
 	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	// use its own transport, so that Close does not affect other clients
+	if c.HTTPClient == nil {
+		c.HTTPClient = &http.Client{
+			Transport: http.DefaultTransport.(*http.Transport).Clone(),
+		}
+	}
+
+	return c
+}
+
+// close idle connections of the underlying http client
+//
+// the client can still be used after Close
+func (c *Client) Close() {
+	c.httpClient().CloseIdleConnections()
+}
+
+// check if given version is in YYYYMMDD format
+func ValidateVersion(version string) error {
+	if len(version) != len(versionLayout) {
+		return fmt.Errorf("invalid version: '%s' (should be in YYYYMMDD format)", version)
+	}
+	if _, err := time.Parse(versionLayout, version); err != nil {
+		return fmt.Errorf("invalid version: '%s' (should be in YYYYMMDD format)", version)
+	}
+	return nil
+}
+
+// check if given version is older than all SupportedVersions
+func IsVersionDeprecated(version string) bool {
+	return version < SupportedVersions[0]
+}
+
+// send a request to any endpoint (eg. the ones which are not wrapped yet), and return the raw response body
+//
+// path is relative to BaseURL (eg. "/voices"), query is sent as GET parameters, and body is sent as json (nothing when nil)
+//
+// error status codes are returned as *APIError
+func (c *Client) Do(method, path string, query map[string]interface{}, body interface{}) (response []byte, err error) {
+	return c.DoContext(context.Background(), method, path, query, body)
+}
+
+// send a request to any endpoint with given context.Context, and return the raw response body
+func (c *Client) DoContext(ctx context.Context, method, path string, query map[string]interface{}, body interface{}) (response []byte, err error) {
+	url := c.makeUrl(path, query)
+
+	if response, err = c.request(ctx, method, *url, body); err != nil {
+		err = fmt.Errorf("%s %s request error: %w", method, path, err)
+	}
+
+	return response, err
+}
+
+// send a request to any endpoint, and decode the response into given out (eg. a pointer to a custom struct)
+func (c *Client) DoInto(method, path string, query map[string]interface{}, body, out interface{}) (err error) {
+	return c.DoIntoContext(context.Background(), method, path, query, body, out)
+}
+
+// send a request to any endpoint with given context.Context, and decode the response into given out
+func (c *Client) DoIntoContext(ctx context.Context, method, path string, query map[string]interface{}, body, out interface{}) (err error) {
+	var bytes []byte
+	if bytes, err = c.DoContext(ctx, method, path, query, body); err == nil {
+		err = decodeInto(bytes, out, fmt.Sprintf("%s %s", method, path))
+	}
+
+	return err
+}
+
+// send http request with given context, method, url, and body data
+//
+// no body is sent when body is nil
+func (c *Client) request(ctx context.Context, method, url string, body interface{}) (res []byte, err error) {
+	var req *http.Request
+	if req, err = c.newJSONRequest(ctx, method, url, body); err == nil {
+		res, err = c.send(req, method == "GET")
+	}
+
+	return res, err
+}
+
+// build http request with given context, method, url, and body data (sent as json)
+//
+// no body is sent when body is nil
+func (c *Client) newJSONRequest(ctx context.Context, method, url string, body interface{}) (req *http.Request, err error) {
+	var data []byte
+	if body != nil {
+		data, err = json.Marshal(body)
+	}
+	if err == nil {
+		if c.Verbose {
+			logged := string(data)
+			if c.RedactSensitive {
+				logged = redacted
+			}
+			c.verbose("< HTTP request: %s %s, %s", method, c.redact(url), c.redact(logged))
+		}
+
+		// compress large bodies
+		compressed := false
+		if c.CompressRequests && len(data) >= minCompressSize {
+			var buf bytes.Buffer
+			writer := gzip.NewWriter(&buf)
+			if _, err = writer.Write(data); err == nil {
+				err = writer.Close()
+			}
+			if err != nil {
+				return nil, fmt.Errorf("error while compressing request body: %w", err)
+			}
+			data, compressed = buf.Bytes(), true
+		}
+
+		if req, err = http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(data)); err == nil {
+			// headers
+			c.setHeaders(req)
+			if len(data) > 0 {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if compressed {
+				req.Header.Set("Content-Encoding", "gzip")
+			}
+		} else {
+			err = fmt.Errorf("error while building request: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("error while building request body: %w", err)
+	}
+
+	return req, err
+}
+
+// upload data (voice, zip archive, ...) from given reader
+//
+// contentLength can be -1 if it is unknown
+func (c *Client) upload(ctx context.Context, method, url string, reader io.Reader, contentLength int64, contentType string) (res []byte, err error) {
+	if err = validateContentType(contentType); err != nil {
+		return res, err
+	}
+
+	c.verbose("< HTTP request: %s %s, %d bytes (%s)", method, c.redact(url), contentLength, contentType)
+
+	// wrap reader not to be closed by http client, so it can be rewound on retries
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, method, url, ioutil.NopCloser(reader)); err == nil {
+		if contentLength >= 0 {
+			req.ContentLength = contentLength
+		} else {
+			req.TransferEncoding = []string{"chunked"}
+		}
+		if seeker, ok := reader.(io.Seeker); ok {
+			// rewind to the starting offset (not 0), for the reader may have been read partially
+			if offset, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+				req.GetBody = func() (io.ReadCloser, error) {
+					if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+						return nil, err
+					}
+					return ioutil.NopCloser(reader), nil
+				}
+			}
+		}
+
+		// headers
+		c.setHeaders(req)
+		req.Header.Set("Content-Type", contentType)
+
+		res, err = c.send(req, c.Retry != nil && c.Retry.RetrySpeech)
+	} else {
+		err = fmt.Errorf("error while building request: %w", err)
+	}
+
+	return res, err
+}
+
+// set common headers of given request
+//
+// custom headers (of Client and of request's context) cannot overwrite Authorization and Accept
+// (Accept can be overwritten with ContextWithAccept or ContextWithVersion)
+func (c *Client) setHeaders(req *http.Request) {
+	userAgent := c.UserAgent
+	if len(userAgent) == 0 {
+		userAgent = DefaultUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	for key, value := range c.Headers {
+		req.Header.Set(key, value)
+	}
+	if headers, ok := req.Context().Value(headersKey{}).(map[string]string); ok {
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+	}
+
+	req.Header.Set("Authorization", *c.headerAuth)
+	if accept, ok := req.Context().Value(acceptKey{}).(string); ok {
+		req.Header.Set("Accept", accept)
+	} else if version, ok := req.Context().Value(versionKey{}).(string); ok {
+		req.Header.Set("Accept", fmt.Sprintf("application/vnd.wit.%s+json", version))
+	} else {
+		req.Header.Set("Accept", *c.headerAccept)
+	}
+}
+
+// API version for requests made with given context.Context (Client.Version when not given)
+func (c *Client) versionOf(ctx context.Context) string {
+	if version, ok := ctx.Value(versionKey{}).(string); ok {
+		return version
+	}
+	return *c.Version
+}
+
+// key for custom headers in context.Context
+type headersKey struct{}
+
+// new context.Context with custom headers for requests made with it
+//
+// they are merged into Client.Headers (overwriting the ones with the same keys)
+func ContextWithHeaders(ctx context.Context, headers map[string]string) context.Context {
+	merged := map[string]string{}
+	if existing, ok := ctx.Value(headersKey{}).(map[string]string); ok {
+		for key, value := range existing {
+			merged[key] = value
+		}
+	}
+	for key, value := range headers {
+		merged[key] = value
+	}
+	return context.WithValue(ctx, headersKey{}, merged)
+}
+
+// key for Accept header in context.Context
+type acceptKey struct{}
+
+// new context.Context with given Accept header for requests made with it
+//
+// eg. for synthesizing speech in other formats: ContextWithAccept(ctx, SynthesizeTypePcm16)
+func ContextWithAccept(ctx context.Context, accept string) context.Context {
+	return context.WithValue(ctx, acceptKey{}, accept)
+}
+
+// key for API version in context.Context
+type versionKey struct{}
+
+// new context.Context with given API version for requests made with it (overriding Client.Version)
+//
+// eg. for comparing responses of two versions with a single client
+//
+// returns given context.Context as it is, with an error when given version is not in YYYYMMDD format
+func ContextWithVersion(ctx context.Context, version string) (context.Context, error) {
+	if err := ValidateVersion(version); err != nil {
+		return ctx, err
+	}
+
+	return context.WithValue(ctx, versionKey{}, version), nil
+}
+
+// context.Context for sending requests and getting responses in the legacy format
+// (eg. Message with outcomes, entities with values, converse steps, and deprecated intents)
+//
+// LegacyVersion is used, unless a version is given with ContextWithVersion
+func withLegacyVersion(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(versionKey{}).(string); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, versionKey{}, LegacyVersion)
+}
+
+// check if given content type is a valid media type (and a supported one, for audio)
+func validateContentType(contentType string) error {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("%w: '%s' (%s)", ErrInvalidContentType, contentType, err)
+	}
+	if strings.HasPrefix(mediaType, "audio/") && !supportedAudioTypes[mediaType] {
+		return fmt.Errorf("%w: '%s' is not a supported audio type", ErrInvalidContentType, contentType)
+	}
+	return nil
+}
+
+// send given http request and read its response body, retrying if needed
+//
+// returns *APIError when the response status code is >= 400
+func (c *Client) send(req *http.Request, retryable bool) (res []byte, err error) {
+	maxAttempts := 1
+	if retryable && c.Retry != nil && c.Retry.MaxAttempts > 1 {
+		maxAttempts = c.Retry.MaxAttempts
+	}
+
+	started := time.Now()
+	for attempt := 1; ; attempt++ {
+		var retryAfter time.Duration
+		res, retryAfter, err = c.sendOnce(req)
+
+		if attempt >= maxAttempts || !shouldRetry(err) {
+			return res, withAttempts(err, attempt)
+		}
+
+		// rewind request body
+		if req.GetBody != nil {
+			body, rewindErr := req.GetBody() // (err is kept for returning it when not retried)
+			if rewindErr != nil {
+				return res, fmt.Errorf("error while rewinding request body: %w", rewindErr)
+			}
+			req.Body = body
+		} else if req.Body != nil && req.Body != http.NoBody {
+			return res, withAttempts(err, attempt) // body cannot be sent again
+		}
+
+		wait := c.Retry.backoff(attempt)
+		if retryAfter > 0 {
+			wait = retryAfter
+		}
+
+		// give up when the next attempt would exceed the total budget
+		if c.Retry.MaxElapsedTime > 0 && time.Since(started)+wait > c.Retry.MaxElapsedTime {
+			return res, withAttempts(err, attempt)
+		}
+
+		c.verbose("* retrying request in %s (attempt: %d/%d)", wait, attempt+1, maxAttempts)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return res, fmt.Errorf("error while waiting for retry: %w", req.Context().Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// wrap given error with the number of attempts, if it was retried
+func withAttempts(err error, attempts int) error {
+	if err != nil && attempts > 1 {
+		return fmt.Errorf("%w (after %d attempts)", err, attempts)
+	}
+	return err
+}
+
+// send given http request once and read its response body
+//
+// also returns the duration from `Retry-After` header, if any
+func (c *Client) sendOnce(req *http.Request) (res []byte, retryAfter time.Duration, err error) {
+	statusCode := 0
+	started := time.Now()
+	if c.OnRequestComplete != nil {
+		defer func() {
+			c.OnRequestComplete(req.Method, c.redact(req.URL.String()), statusCode, time.Since(started), err)
+		}()
+	}
+
+	client := c.doer()
+	if noRedirect, _ := req.Context().Value(noRedirectKey{}).(bool); noRedirect {
+		client = withoutRedirects(client)
+	}
+
+	var resp *http.Response
+	if resp, err = client.Do(req); err == nil {
+		defer resp.Body.Close()
+
+		statusCode = resp.StatusCode
+		c.updateRateLimit(resp.Header)
+
+		if res, err = c.readBody(resp.Body); err == nil {
+			c.verbose("> HTTP response: %d %s", resp.StatusCode, string(res))
+
+			if resp.StatusCode >= 400 {
+				err = newAPIError(resp.StatusCode, res)
+				retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+			} else if isRedirect(resp.StatusCode) {
+				err = &redirectError{StatusCode: resp.StatusCode, Location: resp.Header.Get("Location")}
+			} else if looksLikeHTML(resp.Header.Get("Content-Type"), res) {
+				err = fmt.Errorf("%w (status: %d): %s", ErrUnexpectedResponse, resp.StatusCode, bodySnippet(res))
+			}
+		} else {
+			err = fmt.Errorf("error while reading response: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("error while sending request: %w", err)
+	}
+
+	return res, retryAfter, err
+}
+
+// send given http request once and copy its response body to given writer
+//
+// (response body is not retried nor logged, for it can be large binary data)
+func (c *Client) sendStream(req *http.Request, w io.Writer) (written int64, err error) {
+	statusCode := 0
+	started := time.Now()
+	if c.OnRequestComplete != nil {
+		defer func() {
+			c.OnRequestComplete(req.Method, c.redact(req.URL.String()), statusCode, time.Since(started), err)
+		}()
+	}
+
+	var resp *http.Response
+	if resp, err = c.doer().Do(req); err == nil {
+		defer resp.Body.Close()
+
+		statusCode = resp.StatusCode
+		c.updateRateLimit(resp.Header)
+
+		if isRedirect(resp.StatusCode) {
+			err = &redirectError{StatusCode: resp.StatusCode, Location: resp.Header.Get("Location")}
+		} else if resp.StatusCode < 400 {
+			if written, err = io.Copy(w, resp.Body); err == nil {
+				c.verbose("> HTTP response: %d (%d bytes of %s)", resp.StatusCode, written, resp.Header.Get("Content-Type"))
+			} else {
+				err = fmt.Errorf("error while copying response: %w", err)
+			}
+		} else {
+			var res []byte
+			if res, err = c.readBody(resp.Body); err == nil {
+				c.verbose("> HTTP response: %d %s", resp.StatusCode, string(res))
+
+				err = newAPIError(resp.StatusCode, res)
+			} else {
+				err = fmt.Errorf("error while reading response: %w", err)
+			}
+		}
+	} else {
+		err = fmt.Errorf("error while sending request: %w", err)
+	}
+
+	return written, err
+}
+
+// read given response body, up to MaxResponseBytes
+func (c *Client) readBody(body io.Reader) (res []byte, err error) {
+	limit := c.MaxResponseBytes
+	if limit == 0 {
+		limit = DefaultMaxResponseBytes
+	}
+	if limit < 0 {
+		return ioutil.ReadAll(body)
+	}
+
+	// read one more byte for checking truncation
+	if res, err = ioutil.ReadAll(io.LimitReader(body, limit+1)); err == nil && int64(len(res)) > limit {
+		return res[:limit], fmt.Errorf("%w (limit: %d bytes)", ErrResponseTooLarge, limit)
+	}
+
+	return res, err
+}
+
+// key for not following redirects in context.Context
+type noRedirectKey struct{}
+
+// copy of given sender which does not follow redirects (other Doers are returned as they are)
+func withoutRedirects(doer Doer) Doer {
+	if client, ok := doer.(*http.Client); ok {
+		copied := *client
+		copied.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+		return &copied
+	}
+	return doer
+}
+
+// check if given status code is of a redirect
+func isRedirect(statusCode int) bool {
+	return statusCode >= 300 && statusCode < 400
+}
+
+// error returned when a redirect response was not followed
+type redirectError struct {
+	StatusCode int
+	Location   string // empty when not given
+}
+
+func (e *redirectError) Error() string {
+	return fmt.Sprintf("%s (status: %d, location: '%s')", ErrUnexpectedRedirect, e.StatusCode, e.Location)
+}
+
+func (e *redirectError) Unwrap() error {
+	return ErrUnexpectedRedirect
+}
+
+// save rate limit values from given response headers, if any
+//
+// they are not saved without `X-RateLimit-Remaining`,
+// for a missing one should not be taken as an exhausted rate limit (see waitForRateLimit)
+func (c *Client) updateRateLimit(header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	limit, _ := strconv.Atoi(header.Get("X-RateLimit-Limit")) // (0 when not given)
+
+	rateLimit := RateLimit{
+		Limit:     limit,
+		Remaining: remaining,
+		UpdatedAt: time.Now(),
+	}
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		rateLimit.Reset = time.Unix(reset, 0)
+	}
+
+	c.mutex.Lock()
+	c.rateLimit = &rateLimit
+	c.mutex.Unlock()
+}
+
+// rate limit values from the last response which had them
+func (c *Client) LastRateLimit() (rateLimit RateLimit, exists bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.rateLimit != nil {
+		return *c.rateLimit, true
+	}
+	return rateLimit, false
+}
+
+// http client for sending requests
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// sender of requests (Doer when it is set, otherwise the http client)
+func (c *Client) doer() Doer {
+	if c.Doer != nil {
+		return c.Doer
+	}
+	return c.httpClient()
+}
+
+// check if given error is worth retrying (429 or 5xx)
+func shouldRetry(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	return false
+}
+
+// parse the value of `Retry-After` header (in seconds or http date)
+func parseRetryAfter(value string) time.Duration {
+	if len(value) == 0 {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// replace the token in given string, if RedactSensitive is set
+func (c *Client) redact(str string) string {
+	if c.RedactSensitive && c.Token != nil && len(*c.Token) > 0 {
+		return strings.ReplaceAll(str, *c.Token, redacted)
+	}
+	return str
+}
+
+// print verbose messages, if Verbose is set
+//
+// messages go to Logger, and nothing is printed when Logger is nil
+func (c *Client) verbose(format string, v ...interface{}) {
+	if !c.Verbose {
+		return
+	}
+
+	if c.Logger != nil {
+		c.Logger.Printf(format, v...)
+	}
+}
+
+// make request url with given path and GET parameters
+//
+// non-scalar parameter values (maps, structs, slices, ...) are encoded as json
+func (c *Client) makeUrl(path string, params map[string]interface{}) *string {
+	// sort keys for a deterministic order of parameters
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	queries := make([]string, len(keys))
+	for i, k := range keys {
+		queries[i] = fmt.Sprintf("%s=%s", k, url.QueryEscape(paramValue(params[k])))
+	}
+
+	baseUrl := c.BaseURL
+	if len(baseUrl) == 0 {
+		baseUrl = DefaultBaseURL
+	}
+
+	url := strings.TrimSuffix(baseUrl, "/") + path
+	if len(params) > 0 {
+		url = url + "?" + strings.Join(queries, "&")
+	}
+
+	return &url
+}
+
+// escape given free-text value (eg. an entity value or an expression) as a path segment
+//
+// all reserved characters are escaped (eg. "2024/01/01 a+b" => "2024%2F01%2F01%20a%2Bb"),
+// for url.PathEscape leaves some of them (eg. '+' and ':') as they are
+func escapeValue(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+// format given value as a GET parameter value
+func paramValue(v interface{}) string {
+	switch v.(type) {
+	case string, bool,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64:
+		return fmt.Sprintf("%v", v)
+	}
+
+	if data, err := json.Marshal(v); err == nil {
+		return string(data)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// check if the token is valid and the API is reachable
+//
+// returns ErrUnauthorized when the token is not accepted
+func (c *Client) Validate() (err error) {
+	return c.ValidateContext(context.Background())
+}
+
+// check if the token is valid and the API is reachable with given context.Context
+func (c *Client) ValidateContext(ctx context.Context) (err error) {
+	url := c.makeUrl("/entities", nil)
+
+	if _, err = c.request(ctx, "GET", *url, nil); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+			return fmt.Errorf("validate error: %w (%s)", ErrUnauthorized, apiErr)
+		}
+		return fmt.Errorf("validate error: %w", err)
+	}
+
+	return nil
+}
+
+// get meaning of a sentence, in the legacy response format (requested with LegacyVersion)
+//
+// messageId is a client-supplied id of this query (sent as `msg_id`, eg. a trace id for matching utterances in the console),
+// not an id of an existing message to retrieve (see GetMessage_deprecated); it is not sent when empty
+//
+// https://wit.ai/docs/http/20160516#get--message-link
+func (c *Client) QueryMessage(query string, witContext interface{}, messageId, threadId string) (response Message, err error) {
+	return c.QueryMessageContext(context.Background(), query, witContext, messageId, threadId)
+}
+
+// get meaning of a sentence with given context.Context
+//
+// https://wit.ai/docs/http/20160516#get--message-link
+func (c *Client) QueryMessageContext(ctx context.Context, query string, witContext interface{}, messageId, threadId string) (response Message, err error) {
+	return parseMessage(c.queryMessageRaw(withLegacyVersion(ctx), messageParams(query, witContext, messageId, threadId)))
+}
+
+// get meaning of a sentence with n-best intents, in the newer response format
+//
+// n defaults to 1 when it is not positive, and all intents are kept in the response
+// (see MessageV2.IntentsByConfidence for them in the order of confidence)
+//
+// https://wit.ai/docs/http/20200513#get__message_link
+func (c *Client) QueryMessageN(query string, witContext interface{}, messageId, threadId string, n int) (response MessageV2, err error) {
+	return c.QueryMessageNContext(context.Background(), query, witContext, messageId, threadId, n)
+}
+
+// get meaning of a sentence with n-best intents and given context.Context, in the newer response format
+//
+// n defaults to 1 when it is not positive
+//
+// https://wit.ai/docs/http/20200513#get__message_link
+func (c *Client) QueryMessageNContext(ctx context.Context, query string, witContext interface{}, messageId, threadId string, n int) (response MessageV2, err error) {
+	return c.queryMessageV2(ctx, messageParams(query, witContext, messageId, threadId), MessageOptions{N: n})
+}
+
+// get meaning of a sentence as a raw response body (for decoding it into other types)
+//
+// https://wit.ai/docs/http/20160516#get--message-link
+func (c *Client) QueryMessageRaw(query string, witContext interface{}, messageId, threadId string) (response []byte, err error) {
+	return c.QueryMessageRawContext(context.Background(), query, witContext, messageId, threadId)
+}
+
+// get meaning of a sentence with given context.Context as a raw response body
+//
+// https://wit.ai/docs/http/20160516#get--message-link
+func (c *Client) QueryMessageRawContext(ctx context.Context, query string, witContext interface{}, messageId, threadId string) (response []byte, err error) {
+	return c.queryMessageRaw(ctx, messageParams(query, witContext, messageId, threadId))
+}
+
+// get meaning of a sentence with n-best intents, in the newer response format
+//
+// n defaults to 1 when it is not positive
+//
+// https://wit.ai/docs/http/20200513#get__message_link
+func (c *Client) QueryMessageV2(query string, witContext interface{}, n int) (response MessageV2, err error) {
+	return c.QueryMessageV2Context(context.Background(), query, witContext, n)
+}
+
+// get meaning of a sentence with n-best intents and given context.Context, in the newer response format
+//
+// n defaults to 1 when it is not positive
+//
+// https://wit.ai/docs/http/20200513#get__message_link
+func (c *Client) QueryMessageV2Context(ctx context.Context, query string, witContext interface{}, n int) (response MessageV2, err error) {
+	return c.queryMessageV2(ctx, messageParams(query, witContext, "", ""), MessageOptions{N: n})
+}
+
+// query a message with given GET parameters and options, in the newer response format
+func (c *Client) queryMessageV2(ctx context.Context, params map[string]interface{}, options MessageOptions) (response MessageV2, err error) {
+	n := options.N
+	if n <= 0 {
+		n = 1
+	}
+	params["n"] = n
+	if len(options.DynamicEntities) > 0 {
+		params["entities"] = dynamicEntities(options.DynamicEntities)
+	}
+	if len(options.Locale) > 0 {
+		params["locale"] = options.Locale
+	}
+	if len(options.Tag) > 0 {
+		params["tag"] = options.Tag
+	}
+	if len(options.MessageId) > 0 {
+		params["msg_id"] = options.MessageId
+	}
+
+	var bytes []byte
+	if bytes, err = c.queryMessageRaw(ctx, params); err == nil {
+		var msgRes MessageV2
+		if err = json.Unmarshal(bytes, &msgRes); err == nil {
+			if !msgRes.HasError() {
+				response = msgRes
+			} else {
+				err = msgRes.responseError("message")
+			}
+		} else {
+			err = fmt.Errorf("message parse error: %w", err)
+		}
+	}
+
+	if err == nil && options.Threshold != nil {
+		response = response.filterByConfidence(*options.Threshold)
+	}
+
+	return response, err
+}
+
+// dynamic entities in the format of 'entities' GET parameter
+//
+// each value is sent as a keyword (which is also a synonym of itself)
+func dynamicEntities(entities map[string][]string) map[string][]Keyword {
+	converted := map[string][]Keyword{}
+	for name, values := range entities {
+		keywords := []Keyword{}
+		for _, value := range values {
+			keyword := value
+			keywords = append(keywords, Keyword{
+				Keyword:  &keyword,
+				Synonyms: []string{keyword},
+			})
+		}
+		converted[name] = keywords
+	}
+	return converted
+}
+
+// get meaning of a sentence, and decode the response into given out (eg. a pointer to a custom struct)
+//
+// https://wit.ai/docs/http/20200513#get__message_link
+func (c *Client) QueryMessageInto(query string, witContext interface{}, out interface{}) (err error) {
+	return c.QueryMessageIntoContext(context.Background(), query, witContext, out)
+}
+
+// get meaning of a sentence with given context.Context, and decode the response into given out
+//
+// https://wit.ai/docs/http/20200513#get__message_link
+func (c *Client) QueryMessageIntoContext(ctx context.Context, query string, witContext interface{}, out interface{}) (err error) {
+	var bytes []byte
+	if bytes, err = c.queryMessageRaw(ctx, messageParams(query, witContext, "", "")); err == nil {
+		err = decodeInto(bytes, out, "message")
+	}
+
+	return err
+}
+
+// decode given response body into given out, checking errors in the body first
+func decodeInto(bytes []byte, out interface{}, label string) error {
+	var resErr ResponseError
+	if err := json.Unmarshal(bytes, &resErr); err == nil && resErr.HasError() {
+		return resErr.responseError(label)
+	}
+
+	if err := json.Unmarshal(bytes, out); err != nil {
+		return fmt.Errorf("%s parse error: %w", label, err)
+	}
+
+	return nil
+}
+
+// GET parameters for querying a message
+func messageParams(query string, witContext interface{}, messageId, threadId string) map[string]interface{} {
+	params := map[string]interface{}{
+		"q": query,
+	}
+	if witContext != nil {
+		params["context"] = witContext
+	}
+	if len(messageId) > 0 {
+		params["msg_id"] = messageId
+	}
+	if len(threadId) > 0 {
+		params["thread_id"] = threadId
+	}
+	return params
+}
+
+// query a message with given GET parameters and return the raw response body
+func (c *Client) queryMessageRaw(ctx context.Context, params map[string]interface{}) (response []byte, err error) {
+	if _, exists := params["tag"]; !exists && len(c.Tag) > 0 {
+		params["tag"] = c.Tag
+	}
+
+	url := c.makeUrl("/message", params)
+
+	if response, err = c.request(ctx, "GET", *url, nil); err != nil {
+		err = fmt.Errorf("message request error: %w", err)
+	}
+
+	return response, err
+}
+
+// parse given raw response body (and error) of a message query
+func parseMessage(bytes []byte, err error) (Message, error) {
+	var response Message
+	if err == nil {
+		var msgRes Message
+		if err = json.Unmarshal(bytes, &msgRes); err == nil {
+			if !msgRes.HasError() {
+				response = msgRes
+			} else {
+				err = msgRes.responseError("message")
+			}
+		} else {
+			err = fmt.Errorf("message parse error: %w", err)
+		}
+	}
+
+	return response, err
+}
+
+// get meaning of a sentence, preserving roles of detected entities
+//
+// entities of the response are keyed with "name:role", so entities of the same type
+// can be told apart with their roles (see MessageV2.EntitiesWithRole)
+//
+// https://wit.ai/docs/http/20200513#get__message_link
+func (c *Client) QueryMessageWithRoles(query string, witContext interface{}) (response MessageV2, err error) {
+	return c.QueryMessageV2Context(context.Background(), query, witContext, 0)
+}
+
+// get meaning of a sentence with given options, in the newer response format
+//
+// https://wit.ai/docs/http/20200513#get__message_link
+func (c *Client) QueryMessageWithOptions(query string, witContext interface{}, options MessageOptions) (response MessageV2, err error) {
+	return c.QueryMessageWithOptionsContext(context.Background(), query, witContext, options)
+}
+
+// get meaning of a sentence with given context.Context and options, in the newer response format
+//
+// wit.ai does not filter results with a confidence threshold by itself,
+// so intents, entities, and traits below options.Threshold are removed from the response here
+// (and RawJSON of the response is cleared then)
+//
+// https://wit.ai/docs/http/20200513#get__message_link
+func (c *Client) QueryMessageWithOptionsContext(ctx context.Context, query string, witContext interface{}, options MessageOptions) (response MessageV2, err error) {
+	return c.queryMessageV2(ctx, messageParams(query, witContext, "", ""), options)
+}
+
+// run texts of given samples through the message endpoint and compare predicted intents with expected ones
+//
+// the app is not modified, so it can be used as a regression test of an app
+//
+// https://wit.ai/docs/http/20200513#get__message_link
+func (c *Client) EvaluateSamples(samples []Sample) ([]SampleResult, error) {
+	return c.EvaluateSamplesContext(context.Background(), samples, DefaultEvaluateConcurrency)
+}
+
+// run texts of given samples through the message endpoint with given context.Context and concurrency
+//
+// concurrency defaults to 1 when it is not positive
+//
+// results are in the same order as samples; when some queries fail,
+// they are returned with the first error (and errors of each sample are in SampleResult.Error)
+func (c *Client) EvaluateSamplesContext(ctx context.Context, samples []Sample, concurrency int) (results []SampleResult, err error) {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	results = make([]SampleResult, len(samples))
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, concurrency)
+
+	for i, sample := range samples {
+		results[i].Sample = sample
+
+		if sample.Text == nil {
+			results[i].Error = fmt.Errorf("evaluate samples error: no text in sample #%d", i)
+			continue
+		}
+
+		// wait for a free worker
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Error = fmt.Errorf("evaluate samples cancelled: %w", ctx.Err())
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, text string) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+
+			msg, err := c.QueryMessageV2Context(ctx, text, nil, 1)
+			if err != nil {
+				results[i].Error = err
+				return
+			}
+
+			if best, exists := msg.BestIntent(); exists {
+				results[i].Intent = best.Name
+				results[i].Confidence = best.Confidence
+			}
+			results[i].Passed = sameIntent(results[i].Sample.Intent, results[i].Intent)
+		}(i, *sample.Text)
+	}
+
+	wg.Wait()
+
+	failed := 0
+	for _, result := range results {
+		if result.Error != nil {
+			if err == nil {
+				err = result.Error
+			}
+			failed++
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("evaluate samples error: %d of %d sample(s) failed: %w", failed, len(samples), err)
+	}
+
+	return results, err
+}
+
+// check if given intent names are the same (nil means no intent)
+func sameIntent(expected, predicted *string) bool {
+	if expected == nil || predicted == nil {
+		return expected == nil && predicted == nil
+	}
+	return *expected == *predicted
+}
+
+// get meaning of audio (mp3 format)
+//
+// n is handled in the same way as QuerySpeech
+//
+// https://wit.ai/docs/http/20160516#post--speech-link
+func (c *Client) QuerySpeechMp3(filepath string, witContext interface{}, messageId, threadId string, n int) (response Message, err error) {
+	return c.QuerySpeech(filepath, ContentTypeMp3, witContext, messageId, threadId, n)
+}
+
+// get meaning of audio (wav format)
+//
+// n is handled in the same way as QuerySpeech
+//
+// https://wit.ai/docs/http/20160516#post--speech-link
+func (c *Client) QuerySpeechWav(filepath string, witContext interface{}, messageId, threadId string, n int) (response Message, err error) {
+	return c.QuerySpeech(filepath, ContentTypeWav, witContext, messageId, threadId, n)
+}
+
+// get meaning of audio file with given content type, in the legacy response format (requested with LegacyVersion)
+//
+// contentType is sent as-is, so it can include parameters for raw audio, eg:
+// "audio/raw;encoding=signed-integer;bits=16;rate=16000;endian=little"
+//
+// n is the number of outcomes to return:
+//   - positive: sent as it is
+//   - 0: defaults to 1
+//   - negative (eg. OmitN): not sent, so the server's default is used
+//
+// https://wit.ai/docs/http/20160516#post--speech-link
+func (c *Client) QuerySpeech(filepath, contentType string, witContext interface{}, messageId, threadId string, n int) (response Message, err error) {
+	return c.QuerySpeechContext(context.Background(), filepath, contentType, witContext, messageId, threadId, n)
+}
+
+// get meaning of audio file with given context.Context and content type
+//
+// https://wit.ai/docs/http/20160516#post--speech-link
+func (c *Client) QuerySpeechContext(ctx context.Context, filepath, contentType string, witContext interface{}, messageId, threadId string, n int) (response Message, err error) {
+	var file *os.File
+	if file, err = os.Open(filepath); err == nil {
+		defer file.Close()
+
+		// validate wav header before uploading
+		if !c.SkipWavValidation {
+			if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == ContentTypeWav {
+				if _, err = ValidateWav(file); err != nil {
+					return response, fmt.Errorf("speech request error: %w", err)
+				}
+				if _, err = file.Seek(0, io.SeekStart); err != nil {
+					return response, fmt.Errorf("speech request error: %w", err)
+				}
+			}
+		}
+
+		return c.QuerySpeechReaderContext(ctx, file, contentType, witContext, messageId, threadId, n)
+	}
+
+	return response, fmt.Errorf("speech request error: %w", err)
+}
+
+// get meaning of audio from given reader
+//
+// https://wit.ai/docs/http/20160516#post--speech-link
+func (c *Client) QuerySpeechReader(reader io.Reader, contentType string, witContext interface{}, messageId, threadId string, n int) (response Message, err error) {
+	return c.QuerySpeechReaderContext(context.Background(), reader, contentType, witContext, messageId, threadId, n)
+}
+
+// get meaning of audio from given reader with given context.Context
+//
+// https://wit.ai/docs/http/20160516#post--speech-link
+func (c *Client) QuerySpeechReaderContext(ctx context.Context, reader io.Reader, contentType string, witContext interface{}, messageId, threadId string, n int) (response Message, err error) {
+	return c.querySpeech(ctx, reader, readerLength(reader), contentType, witContext, messageId, threadId, n)
+}
+
+// get meaning of audio streamed from given reader, with chunked transfer encoding
+//
+// reader is sent as it is read (without buffering), so it fits live audio input
+//
+// https://wit.ai/docs/http/20160516#post--speech-link
+func (c *Client) QuerySpeechStream(ctx context.Context, reader io.Reader, contentType string, witContext interface{}, messageId, threadId string, n int) (response Message, err error) {
+	return c.querySpeech(ctx, reader, -1, contentType, witContext, messageId, threadId, n)
+}
+
+// get meaning of audio from given reader and its length (-1 if unknown)
+func (c *Client) querySpeech(ctx context.Context, reader io.Reader, contentLength int64, contentType string, witContext interface{}, messageId, threadId string, n int) (response Message, err error) {
+	params := map[string]interface{}{}
+	if witContext != nil {
+		params["context"] = witContext
+	}
+	if len(messageId) > 0 {
+		params["msg_id"] = messageId
+	}
+	if len(threadId) > 0 {
+		params["thread_id"] = threadId
+	}
+	if n == 0 {
+		n = 1
+	}
+	if n > 0 {
+		params["n"] = n
+	}
+	if len(c.Tag) > 0 {
+		params["tag"] = c.Tag
+	}
+
+	url := c.makeUrl("/speech", params)
+
+	// the response can be a stream of json objects (partial transcriptions followed by the final one)
+	var bytes []byte
+	if bytes, err = c.upload(withLegacyVersion(ctx), "POST", *url, reader, contentLength, contentType); err == nil {
+		var objects []json.RawMessage
+		if objects, err = splitJSONStream(bytes); err == nil {
+			var speechRes Message
+			for _, object := range objects {
+				speechRes = Message{}
+				if err = json.Unmarshal(object, &speechRes); err != nil {
+					return response, fmt.Errorf("speech parse error: %w", err)
+				}
+				if speechRes.HasError() {
+					return response, speechRes.responseError("speech")
+				}
+			}
+
+			if len(objects) > 0 {
+				response = speechRes
+			} else {
+				err = fmt.Errorf("speech parse error: empty response")
+			}
+		} else {
+			err = fmt.Errorf("speech parse error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("speech request error: %w", err)
+	}
+
+	return response, err
+}
+
+// length of given reader's remaining data (-1 if unknown)
+func readerLength(reader io.Reader) int64 {
+	switch r := reader.(type) {
+	case *os.File:
+		if info, err := r.Stat(); err == nil && info.Mode().IsRegular() {
+			if offset, err := r.Seek(0, io.SeekCurrent); err == nil {
+				return info.Size() - offset
+			}
+		}
+	case interface{ Len() int }: // *bytes.Buffer, *bytes.Reader, *strings.Reader, ...
+		return int64(r.Len())
+	}
+	return -1
+}
+
+// transcribe audio from given reader, without extracting its meaning
+//
+// https://wit.ai/docs/http/20240304#post__dictation_link
+func (c *Client) Dictation(reader io.Reader, contentType string) (response DictationResult, err error) {
+	return c.DictationContext(context.Background(), reader, contentType)
+}
+
+// transcribe audio from given reader with given context.Context, without extracting its meaning
+//
+// https://wit.ai/docs/http/20240304#post__dictation_link
+func (c *Client) DictationContext(ctx context.Context, reader io.Reader, contentType string) (response DictationResult, err error) {
+	url := c.makeUrl("/dictation", nil)
+
+	var bytes []byte
+	if bytes, err = c.upload(ctx, "POST", *url, reader, readerLength(reader), contentType); err == nil {
+		var objects []json.RawMessage
+		if objects, err = splitJSONStream(bytes); err == nil {
+			texts := []string{}
+			for _, object := range objects {
+				var chunk DictationChunk
+				if err = json.Unmarshal(object, &chunk); err != nil {
+					return response, fmt.Errorf("dictation parse error: %w", err)
+				}
+				if chunk.HasError() {
+					return response, chunk.responseError("dictation")
+				}
+
+				response.Chunks = append(response.Chunks, chunk)
+				if chunk.IsFinal && chunk.Text != nil {
+					texts = append(texts, *chunk.Text)
+				}
+			}
+
+			text := strings.Join(texts, " ")
+			response.Text = &text
+		} else {
+			err = fmt.Errorf("dictation parse error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("dictation request error: %w", err)
+	}
+
+	return response, err
+}
+
+// synthesize speech of given text, and write the audio (in DefaultSynthesizeType) to given writer
+//
+// style, speed, and pitch are not sent when they are empty or not positive
+//
+// https://wit.ai/docs/http/20240304#post__synthesize_link
+func (c *Client) Synthesize(text, voice, style string, speed, pitch float32, w io.Writer) (err error) {
+	return c.SynthesizeContext(context.Background(), text, voice, style, speed, pitch, w)
+}
+
+// synthesize speech of given text with given context.Context, and write the audio to given writer
+//
+// audio format can be given with ContextWithAccept (DefaultSynthesizeType when not given)
+//
+// https://wit.ai/docs/http/20240304#post__synthesize_link
+func (c *Client) SynthesizeContext(ctx context.Context, text, voice, style string, speed, pitch float32, w io.Writer) (err error) {
+	// version is given as a parameter, for the Accept header is used for the audio format
+	url := c.makeUrl("/synthesize", map[string]interface{}{
+		"v": c.versionOf(ctx),
+	})
+
+	body := map[string]interface{}{
+		"q":     text,
+		"voice": voice,
+	}
+	if len(style) > 0 {
+		body["style"] = style
+	}
+	if speed > 0 {
+		body["speed"] = speed
+	}
+	if pitch > 0 {
+		body["pitch"] = pitch
+	}
+
+	var req *http.Request
+	if req, err = c.newJSONRequest(ctx, "POST", *url, body); err == nil {
+		if _, ok := ctx.Value(acceptKey{}).(string); !ok {
+			req.Header.Set("Accept", DefaultSynthesizeType)
+		}
+
+		if _, err = c.sendStream(req, w); err != nil {
+			err = fmt.Errorf("synthesize request error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("synthesize request error: %w", err)
+	}
+
+	return err
+}
+
+// retrieve all available voices for synthesizing speech, grouped by their locales
+//
+// https://wit.ai/docs/http/20240304#get__voices_link
+func (c *Client) GetVoices() (response map[string][]Voice, err error) {
+	return c.GetVoicesContext(context.Background())
+}
+
+// retrieve all available voices for synthesizing speech, grouped by their locales with given context.Context
+//
+// https://wit.ai/docs/http/20240304#get__voices_link
+func (c *Client) GetVoicesContext(ctx context.Context) (response map[string][]Voice, err error) {
+	url := c.makeUrl("/voices", nil)
+
+	var bytes []byte
+	if bytes, err = c.request(ctx, "GET", *url, nil); err == nil {
+		var voicesRes map[string][]Voice
+		if err = json.Unmarshal(bytes, &voicesRes); err == nil {
+			response = voicesRes
+		} else {
+			err = fmt.Errorf("get voices parse error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("get voices request error: %w", err)
+	}
+
+	return response, err
+}
+
+// get next steps
+//
+// https://wit.ai/docs/http/20160516#post--converse-link
+func (c *Client) ConverseFirst(sessionId, query string, witContext interface{}) (response Converse, err error) {
+	return c.ConverseFirstContext(context.Background(), sessionId, query, witContext)
+}
+
+// get next steps with given context.Context
+//
+// https://wit.ai/docs/http/20160516#post--converse-link
+func (c *Client) ConverseFirstContext(ctx context.Context, sessionId, query string, witContext interface{}) (response Converse, err error) {
+	params := map[string]interface{}{
+		"session_id": sessionId,
+	}
+	if len(query) > 0 {
+		params["q"] = query
+	}
+
+	url := c.makeUrl("/converse", params)
+
+	var bytes []byte
+	if bytes, err = c.request(withLegacyVersion(ctx), "POST", *url, witContext); err == nil {
+		var converseRes Converse
+		if err = json.Unmarshal(bytes, &converseRes); err == nil {
+			if !converseRes.HasError() {
+				response = converseRes
+			} else {
+				err = converseRes.responseError("converse")
+			}
+		} else {
+			err = fmt.Errorf("converse parse error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("converse request error: %w", err)
+	}
+
+	return response, err
+}
+
+// get next steps with given typed context (eg. with reference time and timezone for resolving datetime entities)
+//
+// for a context.Context, pass the typed context to ConverseFirstContext
+//
+// https://wit.ai/docs/http/20160516#post--converse-link
+func (c *Client) ConverseFirstWithContext(sessionId, query string, witContext *Context) (response Converse, err error) {
+	if witContext == nil {
+		return c.ConverseFirstContext(context.Background(), sessionId, query, nil)
+	}
+	return c.ConverseFirstContext(context.Background(), sessionId, query, witContext)
+}
+
+// get next steps with given context (eg. with merged entities and state flags)
+//
+// https://wit.ai/docs/http/20160516#post--converse-link
+func (c *Client) ConverseNext(sessionId string, witContext *ConverseContext) (response Converse, err error) {
+	return c.ConverseNextContext(context.Background(), sessionId, witContext)
+}
+
+// get next steps with given context.Context and context
+//
+// https://wit.ai/docs/http/20160516#post--converse-link
+func (c *Client) ConverseNextContext(ctx context.Context, sessionId string, witContext *ConverseContext) (response Converse, err error) {
+	if witContext == nil {
+		return c.ConverseFirstContext(ctx, sessionId, "", nil)
+	}
+	return c.ConverseFirstContext(ctx, sessionId, "", witContext)
+}
+
+func (c *Client) ConverseAll(sessionId, query string, witContext interface{}, maxSteps int) (responses []Converse, err error) {
+	return c.ConverseAllContext(context.Background(), sessionId, query, witContext, maxSteps)
+}
+
+// get all steps until 'stop', or given context.Context is done
+//
+// maxSteps defaults to DefaultConverseMaxSteps when it is not positive,
+// and ErrConverseMaxSteps is returned when it is exceeded
+//
+// a step without type is treated as 'stop'
+func (c *Client) ConverseAllContext(ctx context.Context, sessionId, query string, witContext interface{}, maxSteps int) (responses []Converse, err error) {
+	if maxSteps <= 0 {
+		maxSteps = DefaultConverseMaxSteps
+	}
+
+	if result, err := c.ConverseFirstContext(ctx, sessionId, query, witContext); err == nil {
+		responses = append(responses, result)
+
+		for !result.IsStop() {
+			if len(responses) >= maxSteps {
+				return nil, fmt.Errorf("converse error: %w (%d)", ErrConverseMaxSteps, maxSteps)
+			}
+			if err := ctx.Err(); err != nil {
+				return nil, fmt.Errorf("converse cancelled: %w", err)
+			}
+
+			if result, err = c.ConverseFirstContext(ctx, sessionId, "", witContext); err == nil {
+				responses = append(responses, result)
+			} else {
+				return nil, err
+			}
+		}
+
+		return responses, nil
+	} else {
+		return nil, err
+	}
+}
+
+// stream all steps until 'stop', as each of them arrives
+//
+// both channels are closed after the last step; at most one error (eg. ErrConverseMaxSteps
+// after DefaultConverseMaxSteps steps, or cancellation of given context.Context) is sent before that
+func (c *Client) ConverseStream(ctx context.Context, sessionId, query string, witContext interface{}) (<-chan Converse, <-chan error) {
+	steps := make(chan Converse)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(steps)
+
+		for count := 1; ; count++ {
+			result, err := c.ConverseFirstContext(ctx, sessionId, query, witContext)
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			select {
+			case steps <- result:
+			case <-ctx.Done():
+				errs <- fmt.Errorf("converse cancelled: %w", ctx.Err())
+				return
+			}
+
+			if result.IsStop() {
+				return
+			}
+			if count >= DefaultConverseMaxSteps {
+				errs <- fmt.Errorf("converse error: %w (%d)", ErrConverseMaxSteps, DefaultConverseMaxSteps)
+				return
+			}
+			if err := ctx.Err(); err != nil {
+				errs <- fmt.Errorf("converse cancelled: %w", err)
+				return
+			}
+
+			query = "" // only for the first step
+		}
+	}()
+
+	return steps, errs
+}
+
+// run the converse protocol with given handler until 'stop'
+//
+// the context returned from handler's Merge and Action is used for the next steps,
+// and the last context is returned
+//
+// https://wit.ai/docs/http/20160516#post--converse-link
+func (c *Client) RunConverse(sessionId, query string, witContext interface{}, handler ConverseHandler) (result interface{}, err error) {
+	return c.RunConverseContext(context.Background(), sessionId, query, witContext, handler)
+}
+
+// run the converse protocol with given handler and context.Context until 'stop'
+//
+// returns ErrConverseMaxSteps when it does not stop in DefaultConverseMaxSteps steps
+//
+// https://wit.ai/docs/http/20160516#post--converse-link
+func (c *Client) RunConverseContext(ctx context.Context, sessionId, query string, witContext interface{}, handler ConverseHandler) (result interface{}, err error) {
+	var step Converse
+	if step, err = c.ConverseFirstContext(ctx, sessionId, query, witContext); err != nil {
+		return witContext, err
+	}
+
+	for steps := 1; !step.IsStop(); steps++ {
+		switch *step.Type {
+		case "msg":
+			if step.Message != nil {
+				handler.Say(*step.Message)
+			}
+		case "merge":
+			witContext = handler.Merge(step.Entities, witContext)
+		case "action":
+			if step.Action != nil {
+				witContext = handler.Action(*step.Action, witContext)
+			}
+		default:
+			return witContext, fmt.Errorf("converse error: unknown step type '%s'", *step.Type)
+		}
+
+		if steps >= DefaultConverseMaxSteps {
+			return witContext, fmt.Errorf("converse error: %w (%d)", ErrConverseMaxSteps, DefaultConverseMaxSteps)
+		}
+		if err = ctx.Err(); err != nil {
+			return witContext, fmt.Errorf("converse cancelled: %w", err)
+		}
+
+		if step, err = c.ConverseFirstContext(ctx, sessionId, "", witContext); err != nil {
+			return witContext, err
+		}
+	}
+
+	return witContext, nil
+}
+
+// detect the language of a sentence
+//
+// https://wit.ai/docs/http/20200513#get__language_link
+func (c *Client) DetectLanguage(query string, n int) (response LanguageDetection, err error) {
+	return c.DetectLanguageContext(context.Background(), query, n)
+}
+
+// detect the language of a sentence with given context.Context
+//
+// https://wit.ai/docs/http/20200513#get__language_link
+func (c *Client) DetectLanguageContext(ctx context.Context, query string, n int) (response LanguageDetection, err error) {
+	params := map[string]interface{}{
+		"q": query,
+	}
+	if n <= 0 {
+		n = 1
+	}
+	params["n"] = n
+
+	url := c.makeUrl("/language", params)
+
+	var bytes []byte
+	if bytes, err = c.request(ctx, "GET", *url, nil); err == nil {
+		var languageRes LanguageDetection
+		if err = json.Unmarshal(bytes, &languageRes); err == nil {
+			if !languageRes.HasError() {
+				response = languageRes
+			} else {
+				err = languageRes.responseError("detect language")
+			}
+		} else {
+			err = fmt.Errorf("detect language parse error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("detect language request error: %w", err)
+	}
+
+	return response, err
+}
+
+// retrieve the list of all available entities
+//
+// https://wit.ai/docs/http/20160516#get--entities-link
+func (c *Client) GetAllEntities() (response []string, err error) {
+	return c.GetAllEntitiesContext(context.Background())
+}
+
+// retrieve the list of all available entities with given context.Context
+//
+// it pages through GetEntitiesContext until all entities are retrieved
+//
+// https://wit.ai/docs/http/20160516#get--entities-link
+func (c *Client) GetAllEntitiesContext(ctx context.Context) (response []string, err error) {
+	response = []string{}
+	seen := map[string]bool{}
+
+	for offset := 0; ; offset += MaxEntitiesLimit {
+		var entities []EntitySummary
+		if entities, err = c.GetEntitiesContext(ctx, MaxEntitiesLimit, offset); err != nil {
+			return nil, fmt.Errorf("get all entities error: %w", err)
+		}
+
+		added := 0
+		for _, entity := range entities {
+			if entity.Name != nil && !seen[*entity.Name] {
+				seen[*entity.Name] = true
+				response = append(response, *entity.Name)
+				added++
+			}
+		}
+
+		// stop when the server ignores limit and offset (eg. legacy versions), and responds with the same page again
+		if len(entities) < MaxEntitiesLimit || added == 0 {
+			break
+		}
+	}
+
+	return response, nil
+}
+
+// retrieve a page of entities
+//
+// limit is capped to MaxEntitiesLimit
+//
+// https://wit.ai/docs/http/20200513#get__entities_link
+func (c *Client) GetEntities(limit, offset int) (response []EntitySummary, err error) {
+	return c.GetEntitiesContext(context.Background(), limit, offset)
+}
+
+// retrieve a page of entities with given context.Context
+//
+// https://wit.ai/docs/http/20200513#get__entities_link
+func (c *Client) GetEntitiesContext(ctx context.Context, limit, offset int) (response []EntitySummary, err error) {
+	if limit <= 0 || limit > MaxEntitiesLimit {
+		limit = MaxEntitiesLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	params := map[string]interface{}{
+		"limit":  limit,
+		"offset": offset,
+	}
+
+	url := c.makeUrl("/entities", params)
+
+	var bytes []byte
+	if bytes, err = c.request(ctx, "GET", *url, nil); err == nil {
+		var entitiesRes []EntitySummary
+		if err = json.Unmarshal(bytes, &entitiesRes); err == nil {
+			response = entitiesRes
+		} else {
+			err = fmt.Errorf("get entities parse error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("get entities request error: %w", err)
+	}
+
+	return response, err
+}
+
+// create a new entity
+//
+// https://wit.ai/docs/http/20160516#post--entities-link
+func (c *Client) CreateEntity(idOrName, doc *string, values ...EntityValue) (response Entity, err error) {
+	return c.CreateEntityContext(context.Background(), idOrName, doc, values...)
+}
+
+// create a new entity with given context.Context
+//
+// https://wit.ai/docs/http/20160516#post--entities-link
+func (c *Client) CreateEntityContext(ctx context.Context, idOrName, doc *string, values ...EntityValue) (response Entity, err error) {
+	url := c.makeUrl("/entities", nil)
+
+	data := map[string]interface{}{
+		"id": *idOrName,
+	}
+	if doc != nil {
+		data["doc"] = *doc
+	}
+	if len(values) > 0 {
+		data["values"] = append([]EntityValue{}, values...)
+	}
+
+	var bytes []byte
+	if bytes, err = c.request(withLegacyVersion(ctx), "POST", *url, data); err == nil {
+		var entityRes Entity
+		if err = json.Unmarshal(bytes, &entityRes); err == nil {
+			if !entityRes.HasError() {
+				response = entityRes
+			} else {
+				err = entityRes.responseError("new entity")
+			}
+		} else {
+			err = fmt.Errorf("new entity parse error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("new entity request error: %w", err)
+	}
+
+	return response, err
+}
+
+// create a new entity with given lookup strategies (eg. LookupFreeText and LookupKeywords)
+//
+// https://wit.ai/docs/http/20200513#post__entities_link
+func (c *Client) CreateEntityWithLookups(name string, lookups []string) (response Entity, err error) {
+	return c.CreateEntityWithLookupsContext(context.Background(), name, lookups)
+}
+
+// create a new entity with given lookup strategies and context.Context
+//
+// https://wit.ai/docs/http/20200513#post__entities_link
+func (c *Client) CreateEntityWithLookupsContext(ctx context.Context, name string, lookups []string) (response Entity, err error) {
+	url := c.makeUrl("/entities", nil)
+
+	body := map[string]interface{}{
+		"name":    name,
+		"roles":   []string{},
+		"lookups": append([]string{}, lookups...),
+	}
+
+	var bytes []byte
+	if bytes, err = c.request(ctx, "POST", *url, body); err == nil {
+		var entityRes Entity
+		if err = json.Unmarshal(bytes, &entityRes); err == nil {
+			if !entityRes.HasError() {
+				response = entityRes
+			} else {
+				err = entityRes.responseError("new entity")
+			}
+		} else {
+			err = fmt.Errorf("new entity parse error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("new entity request error: %w", err)
+	}
+
+	return response, err
+}
+
+// create given entities concurrently, with at most `concurrency` requests at a time
+//
+// results and errors are in the same order as given entities;
+// no more requests are sent after given context.Context is done,
+// or while the rate limit is exhausted (until it is reset)
+func (c *Client) CreateEntitiesBatch(ctx context.Context, entities []Entity, concurrency int) ([]Entity, []error) {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	results := make([]Entity, len(entities))
+	errs := make([]error, len(entities))
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, concurrency)
+
+	for i, entity := range entities {
+		// wait for a free worker
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+		}
+
+		if err := c.waitForRateLimit(ctx); err != nil {
+			for j := i; j < len(entities); j++ {
+				errs[j] = fmt.Errorf("create entities batch cancelled: %w", err)
+			}
+			break
+		}
+
+		idOrName := entity.Id
+		if idOrName == nil {
+			idOrName = entity.Name
+		}
+		if idOrName == nil {
+			errs[i] = fmt.Errorf("create entities batch error: no id or name in entity #%d", i)
+			<-semaphore
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, idOrName, doc *string, values []EntityValue) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+
+			results[i], errs[i] = c.CreateEntityContext(ctx, idOrName, doc, values...)
+		}(i, idOrName, entity.Doc, entity.Values)
+	}
+
+	wg.Wait()
+
+	return results, errs
+}
+
+// wait until the rate limit is reset, if it is exhausted
+func (c *Client) waitForRateLimit(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if rateLimit, exists := c.LastRateLimit(); exists && rateLimit.Remaining <= 0 && !rateLimit.Reset.IsZero() {
+		if wait := time.Until(rateLimit.Reset); wait > 0 {
+			timer := time.NewTimer(wait)
+			defer timer.Stop()
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+
+	return nil
+}
+
+// retrieve all values of an entity
+//
+// https://wit.ai/docs/http/20160516#get--entities-:entity-id-link
+func (c *Client) ShowEntity(entityId *string) (response Entity, err error) {
+	return c.ShowEntityContext(context.Background(), entityId)
+}
+
+// retrieve all values of an entity with given context.Context
+//
+// https://wit.ai/docs/http/20160516#get--entities-:entity-id-link
+func (c *Client) ShowEntityContext(ctx context.Context, entityId *string) (response Entity, err error) {
+	url := c.makeUrl(fmt.Sprintf("/entities/%s", url.PathEscape(*entityId)), nil)
+
+	var bytes []byte
+	if bytes, err = c.request(withLegacyVersion(ctx), "GET", *url, nil); err == nil {
+		var entityRes Entity
+		if err = json.Unmarshal(bytes, &entityRes); err == nil {
+			if !entityRes.HasError() {
+				response = entityRes
+			} else {
+				err = entityRes.responseError("show entity")
+			}
+		} else {
+			err = fmt.Errorf("show entity parse error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("show entity request error: %w", err)
+	}
+
+	return response, err
+}
+
+// update the values of an entity
+//
+// https://wit.ai/docs/http/20160516#put--entities-:entity-id-link
+func (c *Client) UpdateEntity(entityId, doc *string, values ...EntityValue) (response Entity, err error) {
+	return c.UpdateEntityContext(context.Background(), entityId, doc, values...)
+}
+
+// update the values of an entity with given context.Context
+//
+// https://wit.ai/docs/http/20160516#put--entities-:entity-id-link
+func (c *Client) UpdateEntityContext(ctx context.Context, entityId, doc *string, values ...EntityValue) (response Entity, err error) {
+	url := c.makeUrl(fmt.Sprintf("/entities/%s", url.PathEscape(*entityId)), nil)
+
+	body := map[string]interface{}{}
+	if doc != nil {
+		body["doc"] = *doc
+	}
+	if len(values) > 0 {
+		body["values"] = append([]EntityValue{}, values...)
+	}
+
+	var bytes []byte
+	if bytes, err = c.request(withLegacyVersion(ctx), "PUT", *url, body); err == nil {
+		var entityRes Entity
+		if err = json.Unmarshal(bytes, &entityRes); err == nil {
+			if !entityRes.HasError() {
+				response = entityRes
+			} else {
+				err = entityRes.responseError("update entity")
+			}
+		} else {
+			err = fmt.Errorf("update entity parse error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("update entity request error: %w", err)
+	}
+
+	return response, err
+}
+
+// update lookup strategies (eg. LookupFreeText and LookupKeywords) of an entity
+//
+// https://wit.ai/docs/http/20200513#put__entities__entity_link
+func (c *Client) UpdateEntityLookups(entityId string, lookups []string) (response Entity, err error) {
+	return c.UpdateEntityLookupsContext(context.Background(), entityId, lookups)
+}
+
+// update lookup strategies of an entity with given context.Context
+//
+// https://wit.ai/docs/http/20200513#put__entities__entity_link
+func (c *Client) UpdateEntityLookupsContext(ctx context.Context, entityId string, lookups []string) (response Entity, err error) {
+	url := c.makeUrl(fmt.Sprintf("/entities/%s", url.PathEscape(entityId)), nil)
+
+	body := map[string]interface{}{
+		"name":    entityId,
+		"lookups": append([]string{}, lookups...),
+	}
+
+	var bytes []byte
+	if bytes, err = c.request(ctx, "PUT", *url, body); err == nil {
+		var entityRes Entity
+		if err = json.Unmarshal(bytes, &entityRes); err == nil {
+			if !entityRes.HasError() {
+				response = entityRes
+			} else {
+				err = entityRes.responseError("update entity lookups")
+			}
+		} else {
+			err = fmt.Errorf("update entity lookups parse error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("update entity lookups request error: %w", err)
+	}
+
+	return response, err
+}
+
+// remove all values of an entity in a single request
+//
+// https://wit.ai/docs/http/20160516#put--entities-:entity-id-link
+func (c *Client) ClearEntityValues(entityId string) (response Entity, err error) {
+	return c.ClearEntityValuesContext(context.Background(), entityId)
+}
+
+// remove all values of an entity in a single request with given context.Context
+//
+// https://wit.ai/docs/http/20160516#put--entities-:entity-id-link
+func (c *Client) ClearEntityValuesContext(ctx context.Context, entityId string) (response Entity, err error) {
+	url := c.makeUrl(fmt.Sprintf("/entities/%s", url.PathEscape(entityId)), nil)
+
+	// (UpdateEntity does not send empty values)
+	body := map[string]interface{}{
+		"values": []EntityValue{},
+	}
+
+	var bytes []byte
+	if bytes, err = c.request(withLegacyVersion(ctx), "PUT", *url, body); err == nil {
+		var entityRes Entity
+		if err = json.Unmarshal(bytes, &entityRes); err == nil {
+			if !entityRes.HasError() {
+				response = entityRes
+			} else {
+				err = entityRes.responseError("clear entity values")
+			}
+		} else {
+			err = fmt.Errorf("clear entity values parse error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("clear entity values request error: %w", err)
+	}
+
+	return response, err
+}
+
+// create a new entity, or update it when it already exists
+//
+// so it can be retried safely (eg. after a timeout of a successful creation)
+func (c *Client) CreateOrUpdateEntity(entity Entity) (response Entity, err error) {
+	return c.CreateOrUpdateEntityContext(context.Background(), entity)
+}
+
+// create a new entity, or update it when it already exists, with given context.Context
+func (c *Client) CreateOrUpdateEntityContext(ctx context.Context, entity Entity) (response Entity, err error) {
+	idOrName := entity.Id
+	if idOrName == nil {
+		idOrName = entity.Name
+	}
+	if idOrName == nil {
+		return response, fmt.Errorf("create or update entity error: no id or name in entity")
+	}
+
+	if response, err = c.CreateEntityContext(ctx, idOrName, entity.Doc, entity.Values...); errors.Is(err, ErrAlreadyExists) {
+		response, err = c.UpdateEntityContext(ctx, idOrName, entity.Doc, entity.Values...)
+	}
+
+	return response, err
+}
+
+// delete an entity
+//
+// https://wit.ai/docs/http/20160516#delete--entities-:entity-id-link
+func (c *Client) DeleteEntity(entityId *string) (response DeletedResponse, err error) {
+	return c.DeleteEntityContext(context.Background(), entityId)
+}
+
+// delete an entity with given context.Context
+//
+// https://wit.ai/docs/http/20160516#delete--entities-:entity-id-link
+func (c *Client) DeleteEntityContext(ctx context.Context, entityId *string) (response DeletedResponse, err error) {
+	url := c.makeUrl(fmt.Sprintf("/entities/%s", url.PathEscape(*entityId)), nil)
+
+	var bytes []byte
+	if bytes, err = c.request(ctx, "DELETE", *url, nil); err == nil {
+		var entityRes DeletedResponse
+		if err = json.Unmarshal(bytes, &entityRes); err == nil {
+			if !entityRes.HasError() {
+				response = entityRes
+			} else {
+				err = entityRes.responseError("delete entity")
+			}
+		} else {
+			err = fmt.Errorf("delete entity parse error: %w (body: %s)", err, string(bytes))
+		}
+	} else {
+		err = fmt.Errorf("delete entity request error: %w", err)
+	}
+
+	return response, err
+}
+
+// delete all entities which match given filter, and return the names of deleted ones
+//
+// builtin entities (see IsBuiltin) are not deleted when filter is nil
+//
+// it stops on the first failure, returning the names deleted until then
+func (c *Client) DeleteAllEntities(ctx context.Context, filter func(name string) bool) (removed []string, err error) {
+	if filter == nil {
+		filter = func(name string) bool {
+			return !IsBuiltin(name)
+		}
+	}
+
+	removed = []string{}
+
+	var names []string
+	if names, err = c.GetAllEntitiesContext(ctx); err == nil {
+		for _, name := range names {
+			if !filter(name) {
+				continue
+			}
+
+			if err = c.waitForRateLimit(ctx); err != nil {
+				return removed, fmt.Errorf("delete all entities cancelled: %w", err)
+			}
+
+			entityId := name
+			if _, err = c.DeleteEntityContext(ctx, &entityId); err != nil {
+				return removed, fmt.Errorf("delete all entities error (%s): %w", name, err)
+			}
+
+			removed = append(removed, name)
+		}
+	} else {
+		err = fmt.Errorf("delete all entities error: %w", err)
+	}
+
+	return removed, err
+}
+
+// add new values to an entity
+//
+// https://wit.ai/docs/http/20160516#post--entities-:entity-id-values-link
+func (c *Client) CreateEntityValue(entityId, value *string, expressions []string, metadata *string) (response Entity, err error) {
+	return c.CreateEntityValueContext(context.Background(), entityId, value, expressions, metadata)
+}
+
+// add new values to an entity with given context.Context
+//
+// https://wit.ai/docs/http/20160516#post--entities-:entity-id-values-link
+func (c *Client) CreateEntityValueContext(ctx context.Context, entityId, value *string, expressions []string, metadata *string) (response Entity, err error) {
+	url := c.makeUrl(fmt.Sprintf("/entities/%s/values", url.PathEscape(*entityId)), nil)
+
+	body := map[string]interface{}{
+		"value": *value,
+	}
+	if len(expressions) > 0 {
+		body["expressions"] = expressions
+	}
+	if metadata != nil {
+		body["metadata"] = *metadata
+	}
+
+	var bytes []byte
+	if bytes, err = c.request(withLegacyVersion(ctx), "POST", *url, body); err == nil {
+		var entityRes Entity
+		if err = json.Unmarshal(bytes, &entityRes); err == nil {
+			if !entityRes.HasError() {
+				response = entityRes
+			} else {
+				err = entityRes.responseError("create entity value")
+			}
+		} else {
+			err = fmt.Errorf("create entity value parse error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("create entity value request error: %w", err)
+	}
+
+	return response, err
+}
+
+// remove a given value from an entity
+//
+// https://wit.ai/docs/http/20160516#delete--entities-:entity-id-values-link
+func (c *Client) DeleteEntityValue(entityId, entityValue *string) (response DeletedResponse, err error) {
+	return c.DeleteEntityValueContext(context.Background(), entityId, entityValue)
+}
+
+// remove a given value from an entity with given context.Context
+//
+// https://wit.ai/docs/http/20160516#delete--entities-:entity-id-values-link
+func (c *Client) DeleteEntityValueContext(ctx context.Context, entityId, entityValue *string) (response DeletedResponse, err error) {
+	url := c.makeUrl(fmt.Sprintf("/entities/%s/values/%s", url.PathEscape(*entityId), escapeValue(*entityValue)), nil)
+
+	var bytes []byte
+	if bytes, err = c.request(withLegacyVersion(ctx), "DELETE", *url, nil); err == nil {
+		var entityRes DeletedResponse
+		if err = json.Unmarshal(bytes, &entityRes); err == nil {
+			if !entityRes.HasError() {
+				response = entityRes
+			} else {
+				err = entityRes.responseError("delete entity value")
+			}
+		} else {
+			err = fmt.Errorf("delete entity value parse error: %w (body: %s)", err, string(bytes))
+		}
+	} else {
+		err = fmt.Errorf("delete entity value request error: %w", err)
+	}
+
+	return response, err
+}
+
+// create a new expression for an entity
+//
+// https://wit.ai/docs/http/20160516#post--entities-:entity-id-values-:value-id-expressions-link
+func (c *Client) CreateEntityExpression(entityId, entityValue, expression *string) (response Entity, err error) {
+	return c.CreateEntityExpressionContext(context.Background(), entityId, entityValue, expression)
+}
+
+// create a new expression for an entity with given context.Context
+//
+// https://wit.ai/docs/http/20160516#post--entities-:entity-id-values-:value-id-expressions-link
+func (c *Client) CreateEntityExpressionContext(ctx context.Context, entityId, entityValue, expression *string) (response Entity, err error) {
+	url := c.makeUrl(fmt.Sprintf("/entities/%s/values/%s/expressions", url.PathEscape(*entityId), escapeValue(*entityValue)), nil)
+
+	body := map[string]interface{}{
+		"expression": *expression,
+	}
+
+	var bytes []byte
+	if bytes, err = c.request(withLegacyVersion(ctx), "POST", *url, body); err == nil {
+		var entityRes Entity
+		if err = json.Unmarshal(bytes, &entityRes); err == nil {
+			if !entityRes.HasError() {
+				response = entityRes
+			} else {
+				err = entityRes.responseError("create entity expression")
+			}
+		} else {
+			err = fmt.Errorf("create entity expression parse error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("create entity expression request error: %w", err)
+	}
+
+	return response, err
+}
+
+// remove an expression from an entity
+//
+// returns an error which wraps ErrNotFound when the expression does not exist,
+// so it can be checked with errors.Is(err, ErrNotFound)
+//
+// https://wit.ai/docs/http/20160516#delete--entities-:entity-id-values-:value-id-expressions-link
+func (c *Client) DeleteEntityExpression(entityId, entityValue, expression *string) (response DeletedResponse, err error) {
+	return c.DeleteEntityExpressionContext(context.Background(), entityId, entityValue, expression)
+}
+
+// remove an expression from an entity with given context.Context
+//
+// https://wit.ai/docs/http/20160516#delete--entities-:entity-id-values-:value-id-expressions-link
+func (c *Client) DeleteEntityExpressionContext(ctx context.Context, entityId, entityValue, expression *string) (response DeletedResponse, err error) {
+	url := c.makeUrl(fmt.Sprintf("/entities/%s/values/%s/expressions/%s", url.PathEscape(*entityId), escapeValue(*entityValue), escapeValue(*expression)), nil)
+
+	var bytes []byte
+	if bytes, err = c.request(withLegacyVersion(ctx), "DELETE", *url, nil); err == nil {
+		var entityRes DeletedResponse
+		if err = json.Unmarshal(bytes, &entityRes); err == nil {
+			if !entityRes.HasError() {
+				response = entityRes
+			} else {
+				err = entityRes.responseError("delete entity expression")
+			}
+		} else {
+			err = fmt.Errorf("delete entity expression parse error: %w (body: %s)", err, string(bytes))
+		}
+	} else {
+		err = fmt.Errorf("delete entity expression request error: %w", err)
+	}
+
+	return response, err
+}
+
+// remove an expression from all values of an entity which have it, and return the values it was removed from
+//
+// returns an error which wraps ErrNotFound when no value has the expression
+func (c *Client) DeleteEntityValueExpression(entityId, expression string) (values []string, err error) {
+	return c.DeleteEntityValueExpressionContext(context.Background(), entityId, expression)
+}
+
+// remove an expression from all values of an entity which have it, with given context.Context
+//
+// it stops on the first failure, returning the values it was removed from until then
+func (c *Client) DeleteEntityValueExpressionContext(ctx context.Context, entityId, expression string) (values []string, err error) {
+	values = []string{}
+
+	var entity Entity
+	if entity, err = c.ShowEntityContext(ctx, &entityId); err != nil {
+		return values, fmt.Errorf("delete entity value expression error: %w", err)
+	}
+
+	for _, value := range entity.Values {
+		if value.Value == nil || !containsString(value.Expressions, expression) {
+			continue
+		}
+
+		if _, err = c.DeleteEntityExpressionContext(ctx, &entityId, value.Value, &expression); err != nil {
+			return values, fmt.Errorf("delete entity value expression error (%s): %w", *value.Value, err)
+		}
+
+		values = append(values, *value.Value)
+	}
+
+	if len(values) == 0 {
+		err = fmt.Errorf("delete entity value expression error: %w (no value has expression '%s')", ErrNotFound, expression)
+	}
+
+	return values, err
+}
+
+// check if given slice has given string
+func containsString(slice []string, str string) bool {
+	for _, s := range slice {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
+
+// retrieve a page of keywords of a keywords entity
+//
+// limit is capped to MaxKeywordsLimit
+//
+// https://wit.ai/docs/http/20200513#get__entities__entity_link
+func (c *Client) GetEntityKeywords(entityId string, limit, offset int) (response []Keyword, err error) {
+	return c.GetEntityKeywordsContext(context.Background(), entityId, limit, offset)
+}
+
+// retrieve a page of keywords of a keywords entity with given context.Context
+//
+// https://wit.ai/docs/http/20200513#get__entities__entity_link
+func (c *Client) GetEntityKeywordsContext(ctx context.Context, entityId string, limit, offset int) (response []Keyword, err error) {
+	if limit <= 0 || limit > MaxKeywordsLimit {
+		limit = MaxKeywordsLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	params := map[string]interface{}{
+		"limit":  limit,
+		"offset": offset,
+	}
+
+	url := c.makeUrl(fmt.Sprintf("/entities/%s/keywords", url.PathEscape(entityId)), params)
+
+	var bytes []byte
+	if bytes, err = c.request(ctx, "GET", *url, nil); err == nil {
+		var keywordsRes []Keyword
+		if err = json.Unmarshal(bytes, &keywordsRes); err == nil {
+			response = keywordsRes
+		} else {
+			err = fmt.Errorf("get entity keywords parse error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("get entity keywords request error: %w", err)
+	}
+
+	return response, err
+}
+
+// add a new keyword (with synonyms) to a keywords entity
+//
+// https://wit.ai/docs/http/20200513#post__entities__entity_keywords_link
+func (c *Client) AddEntityKeyword(entityId, keyword string, synonyms []string) (response Entity, err error) {
+	return c.AddEntityKeywordContext(context.Background(), entityId, keyword, synonyms)
+}
+
+// add a new keyword (with synonyms) to a keywords entity with given context.Context
+//
+// https://wit.ai/docs/http/20200513#post__entities__entity_keywords_link
+func (c *Client) AddEntityKeywordContext(ctx context.Context, entityId, keyword string, synonyms []string) (response Entity, err error) {
+	url := c.makeUrl(fmt.Sprintf("/entities/%s/keywords", url.PathEscape(entityId)), nil)
+
+	body := Keyword{
+		Keyword:  &keyword,
+		Synonyms: synonyms,
+	}
+
+	var bytes []byte
+	if bytes, err = c.request(ctx, "POST", *url, body); err == nil {
+		var entityRes Entity
+		if err = json.Unmarshal(bytes, &entityRes); err == nil {
+			if !entityRes.HasError() {
+				response = entityRes
+			} else {
+				err = entityRes.responseError("add entity keyword")
+			}
+		} else {
+			err = fmt.Errorf("add entity keyword parse error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("add entity keyword request error: %w", err)
+	}
+
+	return response, err
+}
+
+// remove a keyword from a keywords entity
+//
+// https://wit.ai/docs/http/20200513#delete__entities__entity_keywords__keyword_link
+func (c *Client) DeleteEntityKeyword(entityId, keyword string) (response Entity, err error) {
+	return c.DeleteEntityKeywordContext(context.Background(), entityId, keyword)
+}
+
+// remove a keyword from a keywords entity with given context.Context
+//
+// https://wit.ai/docs/http/20200513#delete__entities__entity_keywords__keyword_link
+func (c *Client) DeleteEntityKeywordContext(ctx context.Context, entityId, keyword string) (response Entity, err error) {
+	url := c.makeUrl(fmt.Sprintf("/entities/%s/keywords/%s", url.PathEscape(entityId), escapeValue(keyword)), nil)
+
+	var bytes []byte
+	if bytes, err = c.request(ctx, "DELETE", *url, nil); err == nil {
+		var entityRes Entity
+		if err = json.Unmarshal(bytes, &entityRes); err == nil {
+			if !entityRes.HasError() {
+				response = entityRes
+			} else {
+				err = entityRes.responseError("delete entity keyword")
+			}
+		} else {
+			err = fmt.Errorf("delete entity keyword parse error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("delete entity keyword request error: %w", err)
+	}
+
+	return response, err
+}
+
+// add a new synonym to a keyword of a keywords entity
+//
+// https://wit.ai/docs/http/20200513#post__entities__entity_keywords__keyword_synonyms_link
+func (c *Client) AddKeywordSynonym(entityId, keyword, synonym string) (response Entity, err error) {
+	return c.AddKeywordSynonymContext(context.Background(), entityId, keyword, synonym)
+}
+
+// add a new synonym to a keyword of a keywords entity with given context.Context
+//
+// https://wit.ai/docs/http/20200513#post__entities__entity_keywords__keyword_synonyms_link
+func (c *Client) AddKeywordSynonymContext(ctx context.Context, entityId, keyword, synonym string) (response Entity, err error) {
+	url := c.makeUrl(fmt.Sprintf("/entities/%s/keywords/%s/synonyms", url.PathEscape(entityId), escapeValue(keyword)), nil)
+
+	body := map[string]interface{}{
+		"synonym": synonym,
+	}
+
+	var bytes []byte
+	if bytes, err = c.request(ctx, "POST", *url, body); err == nil {
+		var entityRes Entity
+		if err = json.Unmarshal(bytes, &entityRes); err == nil {
+			if !entityRes.HasError() {
+				response = entityRes
+			} else {
+				err = entityRes.responseError("add keyword synonym")
+			}
+		} else {
+			err = fmt.Errorf("add keyword synonym parse error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("add keyword synonym request error: %w", err)
+	}
+
+	return response, err
+}
+
+// remove a synonym from a keyword of a keywords entity
+//
+// https://wit.ai/docs/http/20200513#delete__entities__entity_keywords__keyword_synonyms__synonym_link
+func (c *Client) DeleteKeywordSynonym(entityId, keyword, synonym string) (response Entity, err error) {
+	return c.DeleteKeywordSynonymContext(context.Background(), entityId, keyword, synonym)
+}
+
+// remove a synonym from a keyword of a keywords entity with given context.Context
+//
+// https://wit.ai/docs/http/20200513#delete__entities__entity_keywords__keyword_synonyms__synonym_link
+func (c *Client) DeleteKeywordSynonymContext(ctx context.Context, entityId, keyword, synonym string) (response Entity, err error) {
+	url := c.makeUrl(fmt.Sprintf("/entities/%s/keywords/%s/synonyms/%s", url.PathEscape(entityId), escapeValue(keyword), escapeValue(synonym)), nil)
+
+	var bytes []byte
+	if bytes, err = c.request(ctx, "DELETE", *url, nil); err == nil {
+		var entityRes Entity
+		if err = json.Unmarshal(bytes, &entityRes); err == nil {
+			if !entityRes.HasError() {
+				response = entityRes
+			} else {
+				err = entityRes.responseError("delete keyword synonym")
+			}
+		} else {
+			err = fmt.Errorf("delete keyword synonym parse error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("delete keyword synonym request error: %w", err)
+	}
+
+	return response, err
+}
+
+// add a new role to an entity
+//
+// https://wit.ai/docs/http/20200513#post__entities__entity_roles_link
+func (c *Client) CreateEntityRole(entityId, role string) (response Entity, err error) {
+	return c.CreateEntityRoleContext(context.Background(), entityId, role)
+}
+
+// add a new role to an entity with given context.Context
+//
+// https://wit.ai/docs/http/20200513#post__entities__entity_roles_link
+func (c *Client) CreateEntityRoleContext(ctx context.Context, entityId, role string) (response Entity, err error) {
+	url := c.makeUrl(fmt.Sprintf("/entities/%s/roles", url.PathEscape(entityId)), nil)
+
+	body := map[string]interface{}{
+		"name": role,
+	}
+
+	var bytes []byte
+	if bytes, err = c.request(ctx, "POST", *url, body); err == nil {
+		var entityRes Entity
+		if err = json.Unmarshal(bytes, &entityRes); err == nil {
+			if !entityRes.HasError() {
+				response = entityRes
+			} else {
+				err = entityRes.responseError("create entity role")
+			}
+		} else {
+			err = fmt.Errorf("create entity role parse error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("create entity role request error: %w", err)
+	}
+
+	return response, err
+}
+
+// remove a role from an entity
+//
+// https://wit.ai/docs/http/20200513#delete__entities__entity_roles__role_link
+func (c *Client) DeleteEntityRole(entityId, role string) (response Entity, err error) {
+	return c.DeleteEntityRoleContext(context.Background(), entityId, role)
+}
+
+// remove a role from an entity with given context.Context
+//
+// https://wit.ai/docs/http/20200513#delete__entities__entity_roles__role_link
+func (c *Client) DeleteEntityRoleContext(ctx context.Context, entityId, role string) (response Entity, err error) {
+	url := c.makeUrl(fmt.Sprintf("/entities/%s/roles/%s", url.PathEscape(entityId), escapeValue(role)), nil)
+
+	var bytes []byte
+	if bytes, err = c.request(ctx, "DELETE", *url, nil); err == nil {
+		var entityRes Entity
+		if err = json.Unmarshal(bytes, &entityRes); err == nil {
+			if !entityRes.HasError() {
+				response = entityRes
+			} else {
+				err = entityRes.responseError("delete entity role")
+			}
+		} else {
+			err = fmt.Errorf("delete entity role parse error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("delete entity role request error: %w", err)
+	}
+
+	return response, err
+}
+
+// train the app with given utterances
+//
+// https://wit.ai/docs/http/20200513#post__utterances_link
+func (c *Client) CreateUtterances(utterances ...Utterance) (response UtterancesResponse, err error) {
+	return c.CreateUtterancesContext(context.Background(), utterances...)
+}
+
+// train the app with given utterances with given context.Context
+//
+// https://wit.ai/docs/http/20200513#post__utterances_link
+func (c *Client) CreateUtterancesContext(ctx context.Context, utterances ...Utterance) (response UtterancesResponse, err error) {
+	url := c.makeUrl("/utterances", nil)
+
+	body := append([]Utterance{}, utterances...)
+
+	var bytes []byte
+	if bytes, err = c.request(ctx, "POST", *url, body); err == nil {
+		var utterancesRes UtterancesResponse
+		if err = json.Unmarshal(bytes, &utterancesRes); err == nil {
+			if !utterancesRes.HasError() {
+				response = utterancesRes
+			} else {
+				err = utterancesRes.responseError("create utterances")
+			}
+		} else {
+			err = fmt.Errorf("create utterances parse error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("create utterances request error: %w", err)
+	}
+
+	return response, err
+}
+
+// retrieve a page of stored utterances, optionally filtered by intents
+//
+// limit defaults to (and is capped at) MaxUtterancesLimit when it is not positive,
+// and an offset beyond the last utterance results in an empty slice
+//
+// https://wit.ai/docs/http/20200513#get__utterances_link
+func (c *Client) GetUtterances(limit, offset int, intents []string) (response []Utterance, err error) {
+	return c.GetUtterancesContext(context.Background(), limit, offset, intents)
+}
+
+// retrieve a page of stored utterances, optionally filtered by intents with given context.Context
+//
+// https://wit.ai/docs/http/20200513#get__utterances_link
+func (c *Client) GetUtterancesContext(ctx context.Context, limit, offset int, intents []string) (response []Utterance, err error) {
+	if limit <= 0 || limit > MaxUtterancesLimit {
+		limit = MaxUtterancesLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	params := map[string]interface{}{
+		"limit":  limit,
+		"offset": offset,
+	}
+	if len(intents) > 0 {
+		params["intents"] = strings.Join(intents, ",")
+	}
+
+	url := c.makeUrl("/utterances", params)
+
+	var bytes []byte
+	if bytes, err = c.request(ctx, "GET", *url, nil); err == nil {
+		utterancesRes := []Utterance{}
+		if err = json.Unmarshal(bytes, &utterancesRes); err == nil {
+			if utterancesRes == nil {
+				utterancesRes = []Utterance{}
+			}
+			response = utterancesRes
+		} else {
+			err = fmt.Errorf("get utterances parse error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("get utterances request error: %w", err)
+	}
+
+	return response, err
+}
+
+// remove utterances with given texts from the app
+//
+// https://wit.ai/docs/http/20200513#delete__utterances_link
+func (c *Client) DeleteUtterances(texts ...string) (response UtterancesResponse, err error) {
+	return c.DeleteUtterancesContext(context.Background(), texts...)
+}
+
+// remove utterances with given texts from the app with given context.Context
+//
+// https://wit.ai/docs/http/20200513#delete__utterances_link
+func (c *Client) DeleteUtterancesContext(ctx context.Context, texts ...string) (response UtterancesResponse, err error) {
+	url := c.makeUrl("/utterances", nil)
+
+	body := []interface{}{}
+	for _, text := range texts {
+		body = append(body, map[string]string{"text": text})
+	}
+
+	var bytes []byte
+	if bytes, err = c.request(ctx, "DELETE", *url, body); err == nil {
+		var utterancesRes UtterancesResponse
+		if err = json.Unmarshal(bytes, &utterancesRes); err == nil {
+			if !utterancesRes.HasError() {
+				response = utterancesRes
+			} else {
+				err = utterancesRes.responseError("delete utterances")
+			}
+		} else {
+			err = fmt.Errorf("delete utterances parse error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("delete utterances request error: %w", err)
+	}
+
+	return response, err
+}
+
+// retrieve the list of all traits
+//
+// https://wit.ai/docs/http/20200513#get__traits_link
+func (c *Client) GetAllTraits() (response []Trait, err error) {
+	return c.GetAllTraitsContext(context.Background())
+}
+
+// retrieve the list of all traits with given context.Context
+//
+// https://wit.ai/docs/http/20200513#get__traits_link
+func (c *Client) GetAllTraitsContext(ctx context.Context) (response []Trait, err error) {
+	url := c.makeUrl("/traits", nil)
+
+	var bytes []byte
+	if bytes, err = c.request(ctx, "GET", *url, nil); err == nil {
+		var traitsRes []Trait
+		if err = json.Unmarshal(bytes, &traitsRes); err == nil {
+			response = traitsRes
+		} else {
+			err = fmt.Errorf("get all traits parse error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("get all traits request error: %w", err)
+	}
+
+	return response, err
+}
+
+// create a new trait with given values
+//
+// https://wit.ai/docs/http/20200513#post__traits_link
+func (c *Client) CreateTrait(name string, values []string) (response Trait, err error) {
+	return c.CreateTraitContext(context.Background(), name, values)
+}
+
+// create a new trait with given values with given context.Context
+//
+// https://wit.ai/docs/http/20200513#post__traits_link
+func (c *Client) CreateTraitContext(ctx context.Context, name string, values []string) (response Trait, err error) {
+	url := c.makeUrl("/traits", nil)
+
+	body := map[string]interface{}{
+		"name":   name,
+		"values": append([]string{}, values...),
+	}
+
+	var bytes []byte
+	if bytes, err = c.request(ctx, "POST", *url, body); err == nil {
+		var traitRes Trait
+		if err = json.Unmarshal(bytes, &traitRes); err == nil {
+			if !traitRes.HasError() {
+				response = traitRes
+			} else {
+				err = traitRes.responseError("create trait")
+			}
+		} else {
+			err = fmt.Errorf("create trait parse error: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("create trait request error: %w", err)
+	}
+
+	return response, err
 }
 
-// send http request with given method, url, and body data
-func (c *Client) request(method, url string, body interface{}) (res []byte, err error) {
-	var data []byte
-	if data, err = json.Marshal(body); err == nil {
-		if c.Verbose {
-			log.Printf("< HTTP request: %s %s, %s\n", method, url, string(data))
+// retrieve all values of a trait
+//
+// https://wit.ai/docs/http/20200513#get__traits__trait_link
+func (c *Client) ShowTrait(idOrName string) (response Trait, err error) {
+	return c.ShowTraitContext(context.Background(), idOrName)
+}
+
+// retrieve all values of a trait with given context.Context
+//
+// https://wit.ai/docs/http/20200513#get__traits__trait_link
+func (c *Client) ShowTraitContext(ctx context.Context, idOrName string) (response Trait, err error) {
+	url := c.makeUrl(fmt.Sprintf("/traits/%s", url.PathEscape(idOrName)), nil)
+
+	var bytes []byte
+	if bytes, err = c.request(ctx, "GET", *url, nil); err == nil {
+		var traitRes Trait
+		if err = json.Unmarshal(bytes, &traitRes); err == nil {
+			if !traitRes.HasError() {
+				response = traitRes
+			} else {
+				err = traitRes.responseError("show trait")
+			}
+		} else {
+			err = fmt.Errorf("show trait parse error: %w", err)
 		}
+	} else {
+		err = fmt.Errorf("show trait request error: %w", err)
+	}
 
-		var req *http.Request
-		if req, err = http.NewRequest(method, url, bytes.NewBuffer(data)); err == nil {
-			// headers
-			req.Header.Set("Authorization", *c.headerAuth)
-			req.Header.Set("Accept", *c.headerAccept)
-			req.Header.Set("Content-Type", "application/json")
+	return response, err
+}
+
+// add a new value to a trait
+//
+// https://wit.ai/docs/http/20200513#post__traits__trait_values_link
+func (c *Client) CreateTraitValue(traitId, value string) (response Trait, err error) {
+	return c.CreateTraitValueContext(context.Background(), traitId, value)
+}
 
-			var resp *http.Response
-			client := &http.Client{}
-			if resp, err = client.Do(req); err == nil {
-				defer resp.Body.Close()
+// add a new value to a trait with given context.Context
+//
+// https://wit.ai/docs/http/20200513#post__traits__trait_values_link
+func (c *Client) CreateTraitValueContext(ctx context.Context, traitId, value string) (response Trait, err error) {
+	url := c.makeUrl(fmt.Sprintf("/traits/%s/values", url.PathEscape(traitId)), nil)
 
-				res, _ = ioutil.ReadAll(resp.Body)
+	body := map[string]interface{}{
+		"value": value,
+	}
 
-				if c.Verbose {
-					log.Printf("> HTTP response: %s\n", string(res))
-				}
+	var bytes []byte
+	if bytes, err = c.request(ctx, "POST", *url, body); err == nil {
+		var traitRes Trait
+		if err = json.Unmarshal(bytes, &traitRes); err == nil {
+			if !traitRes.HasError() {
+				response = traitRes
 			} else {
-				log.Printf("Error while sending request: %s\n", err.Error())
+				err = traitRes.responseError("create trait value")
 			}
 		} else {
-			log.Printf("Error while building request: %s\n", err.Error())
+			err = fmt.Errorf("create trait value parse error: %w", err)
 		}
 	} else {
-		log.Printf("Error while building request body: %s\n", err.Error())
+		err = fmt.Errorf("create trait value request error: %w", err)
 	}
 
-	return res, err
+	return response, err
 }
 
-// upload voice file
-func (c *Client) upload(method, url, filepath, contentType string) (res []byte, err error) {
-	var data []byte
-	if data, err = ioutil.ReadFile(filepath); err == nil {
-		if c.Verbose {
-			log.Printf("< HTTP request: %s %s, %s (%s)\n", method, url, filepath, contentType)
+// remove a value from a trait
+//
+// https://wit.ai/docs/http/20200513#delete__traits__trait_values__value_link
+func (c *Client) DeleteTraitValue(traitId, value string) (response DeletedResponse, err error) {
+	return c.DeleteTraitValueContext(context.Background(), traitId, value)
+}
+
+// remove a value from a trait with given context.Context
+//
+// https://wit.ai/docs/http/20200513#delete__traits__trait_values__value_link
+func (c *Client) DeleteTraitValueContext(ctx context.Context, traitId, value string) (response DeletedResponse, err error) {
+	url := c.makeUrl(fmt.Sprintf("/traits/%s/values/%s", url.PathEscape(traitId), escapeValue(value)), nil)
+
+	var bytes []byte
+	if bytes, err = c.request(ctx, "DELETE", *url, nil); err == nil {
+		var traitRes DeletedResponse
+		if err = json.Unmarshal(bytes, &traitRes); err == nil {
+			if !traitRes.HasError() {
+				response = traitRes
+			} else {
+				err = traitRes.responseError("delete trait value")
+			}
+		} else {
+			err = fmt.Errorf("delete trait value parse error: %w (body: %s)", err, string(bytes))
 		}
+	} else {
+		err = fmt.Errorf("delete trait value request error: %w", err)
+	}
 
-		var req *http.Request
-		if req, err = http.NewRequest(method, url, bytes.NewBuffer(data)); err == nil {
-			// headers
-			req.Header.Set("Authorization", *c.headerAuth)
-			req.Header.Set("Accept", *c.headerAccept)
-			req.Header.Set("Content-Type", contentType)
+	return response, err
+}
 
-			var resp *http.Response
-			client := &http.Client{}
-			if resp, err = client.Do(req); err == nil {
-				defer resp.Body.Close()
+// get the download url of the app's zip archive
+//
+// https://wit.ai/docs/http/20200513#get__export_link
+func (c *Client) ExportApp() (downloadURL string, err error) {
+	return c.ExportAppContext(context.Background())
+}
 
-				res, _ = ioutil.ReadAll(resp.Body)
+// get the download url of the app's zip archive with given context.Context
+//
+// a redirect to the archive is not followed, and its location is returned instead
+//
+// https://wit.ai/docs/http/20200513#get__export_link
+func (c *Client) ExportAppContext(ctx context.Context) (downloadURL string, err error) {
+	url := c.makeUrl("/export", nil)
 
-				if c.Verbose {
-					log.Printf("> HTTP response: %s\n", string(res))
+	var bytes []byte
+	if bytes, err = c.request(context.WithValue(ctx, noRedirectKey{}, true), "GET", *url, nil); err != nil {
+		// a redirect to the archive is not an error here
+		var redirectErr *redirectError
+		if errors.As(err, &redirectErr) && len(redirectErr.Location) > 0 {
+			return redirectErr.Location, nil
+		}
+	}
+	if err == nil {
+		var exportRes Export
+		if err = json.Unmarshal(bytes, &exportRes); err == nil {
+			if !exportRes.HasError() {
+				if exportRes.Uri != nil {
+					downloadURL = *exportRes.Uri
+				} else {
+					err = fmt.Errorf("export app response error: no uri in response")
 				}
 			} else {
-				log.Printf("Error while sending request: %s\n", err.Error())
+				err = exportRes.responseError("export app")
 			}
 		} else {
-			log.Printf("Error while building request: %s\n", err.Error())
+			err = fmt.Errorf("export app parse error: %w", err)
 		}
+	} else {
+		err = fmt.Errorf("export app request error: %w", err)
 	}
 
-	return res, err
+	return downloadURL, err
 }
 
-// make request url with given base url and GET parameters
-func (c *Client) makeUrl(baseUrl string, params map[string]interface{}) *string {
-	index := 0
-	queries := make([]string, len(params))
-	for k, v := range params {
-		queries[index] = fmt.Sprintf("%s=%s", k, url.QueryEscape(fmt.Sprintf("%v", v)))
-		index++
-	}
+// download the app's zip archive and write it to given writer
+//
+// https://wit.ai/docs/http/20200513#get__export_link
+func (c *Client) DownloadExport(w io.Writer) (err error) {
+	return c.DownloadExportContext(context.Background(), w)
+}
 
-	url := baseUrl
-	if len(params) > 0 {
-		url = url + "?" + strings.Join(queries, "&")
+// download the app's zip archive with given context.Context and write it to given writer
+//
+// it is sent with the client's http client (or Doer), and redirects from the download url are followed
+//
+// https://wit.ai/docs/http/20200513#get__export_link
+func (c *Client) DownloadExportContext(ctx context.Context, w io.Writer) (err error) {
+	var downloadURL string
+	if downloadURL, err = c.ExportAppContext(ctx); err == nil {
+		var req *http.Request
+		if req, err = http.NewRequestWithContext(ctx, "GET", downloadURL, nil); err != nil {
+			return fmt.Errorf("download export request error: %w", err)
+		}
+
+		// (headers for the API, eg. Authorization, are not sent to the download url)
+		userAgent := c.UserAgent
+		if len(userAgent) == 0 {
+			userAgent = DefaultUserAgent
+		}
+		req.Header.Set("User-Agent", userAgent)
+
+		if _, err = c.sendStream(req, w); err != nil {
+			err = fmt.Errorf("download export request error: %w", err)
+		}
 	}
 
-	return &url
+	return err
 }
 
-// get meaning of a sentence
+// create a new app from given zip archive
 //
-// https://wit.ai/docs/http/20160516#get--message-link
-func (c *Client) QueryMessage(query string, context interface{}, messageId, threadId string) (response Message, err error) {
+// https://wit.ai/docs/http/20200513#post__import_link
+func (c *Client) ImportApp(name string, private bool, zip io.Reader) (response ImportResult, err error) {
+	return c.ImportAppContext(context.Background(), name, private, zip)
+}
+
+// create a new app from given zip archive with given context.Context
+//
+// https://wit.ai/docs/http/20200513#post__import_link
+func (c *Client) ImportAppContext(ctx context.Context, name string, private bool, zip io.Reader) (response ImportResult, err error) {
 	params := map[string]interface{}{
-		"q": query,
-	}
-	if context != nil {
-		params["context"] = context
-	}
-	if len(messageId) > 0 {
-		params["msg_id"] = messageId
-	}
-	if len(threadId) > 0 {
-		params["thread_id"] = threadId
+		"name":    name,
+		"private": private,
 	}
 
-	url := c.makeUrl("https://api.wit.ai/message", params)
+	url := c.makeUrl("/import", params)
 
 	var bytes []byte
-	if bytes, err = c.request("GET", *url, context); err == nil {
-		var msgRes Message
-		if err = json.Unmarshal(bytes, &msgRes); err == nil {
-			if !msgRes.HasError() {
-				response = msgRes
+	if bytes, err = c.upload(ctx, "POST", *url, zip, readerLength(zip), "application/zip"); err == nil {
+		var importRes ImportResult
+		if err = json.Unmarshal(bytes, &importRes); err == nil {
+			if !importRes.HasError() {
+				response = importRes
 			} else {
-				err = fmt.Errorf("message response error: %s", msgRes.ErrorMessage())
+				err = importRes.responseError("import app")
 			}
 		} else {
-			err = fmt.Errorf("message parse error: %s", err)
+			err = fmt.Errorf("import app parse error: %w", err)
 		}
 	} else {
-		err = fmt.Errorf("message request error: %s", err)
+		err = fmt.Errorf("import app request error: %w", err)
 	}
 
 	return response, err
 }
 
-// get meaning of audio (mp3 format)
+// retrieve a page of apps
 //
-// https://wit.ai/docs/http/20160516#post--speech-link
-func (c *Client) QuerySpeechMp3(filepath string, context interface{}, messageId, threadId string, n int) (response Message, err error) {
-	params := map[string]interface{}{}
-	if context != nil {
-		params["context"] = context
-	}
-	if len(messageId) > 0 {
-		params["msg_id"] = messageId
+// https://wit.ai/docs/http/20200513#get__apps_link
+func (c *Client) GetApps(limit, offset int) (response []App, err error) {
+	return c.GetAppsContext(context.Background(), limit, offset)
+}
+
+// retrieve a page of apps with given context.Context
+//
+// https://wit.ai/docs/http/20200513#get__apps_link
+func (c *Client) GetAppsContext(ctx context.Context, limit, offset int) (response []App, err error) {
+	if limit <= 0 || limit > MaxAppsLimit {
+		limit = MaxAppsLimit
 	}
-	if len(threadId) > 0 {
-		params["thread_id"] = threadId
+	if offset < 0 {
+		offset = 0
 	}
-	if n <= 0 {
-		n = 1
+
+	params := map[string]interface{}{
+		"limit":  limit,
+		"offset": offset,
 	}
-	params["n"] = n
 
-	url := c.makeUrl("https://api.wit.ai/speech", params)
+	url := c.makeUrl("/apps", params)
 
 	var bytes []byte
-	if bytes, err = c.upload("POST", *url, filepath, "audio/mpeg3"); err == nil {
-		var speechRes Message
-		if err = json.Unmarshal(bytes, &speechRes); err == nil {
-			if !speechRes.HasError() {
-				response = speechRes
-			} else {
-				err = fmt.Errorf("speech response error: %s", speechRes.ErrorMessage())
-			}
+	if bytes, err = c.request(ctx, "GET", *url, nil); err == nil {
+		var appsRes []App
+		if err = json.Unmarshal(bytes, &appsRes); err == nil {
+			response = appsRes
 		} else {
-			err = fmt.Errorf("speech parse error: %s", err)
+			err = fmt.Errorf("get apps parse error: %w", err)
 		}
 	} else {
-		err = fmt.Errorf("speech request error: %s", err)
+		err = fmt.Errorf("get apps request error: %w", err)
 	}
 
 	return response, err
 }
 
-// get next steps
+// retrieve an app, including its training status
 //
-// https://wit.ai/docs/http/20160516#post--converse-link
-func (c *Client) ConverseFirst(sessionId, query string, context interface{}) (response Converse, err error) {
-	params := map[string]interface{}{
-		"session_id": sessionId,
-	}
-	if context != nil {
-		params["context"] = context
-	}
-	if len(query) > 0 {
-		params["q"] = query
-	}
+// https://wit.ai/docs/http/20200513#get__apps__app_link
+func (c *Client) GetApp(appId string) (response App, err error) {
+	return c.GetAppContext(context.Background(), appId)
+}
 
-	url := c.makeUrl("https://api.wit.ai/converse", params)
+// retrieve an app with given context.Context
+//
+// https://wit.ai/docs/http/20200513#get__apps__app_link
+func (c *Client) GetAppContext(ctx context.Context, appId string) (response App, err error) {
+	url := c.makeUrl(fmt.Sprintf("/apps/%s", url.PathEscape(appId)), nil)
 
 	var bytes []byte
-	if bytes, err = c.request("POST", *url, context); err == nil {
-		var converseRes Converse
-		if err = json.Unmarshal(bytes, &converseRes); err == nil {
-			if !converseRes.HasError() {
-				response = converseRes
+	if bytes, err = c.request(ctx, "GET", *url, nil); err == nil {
+		var appRes App
+		if err = json.Unmarshal(bytes, &appRes); err == nil {
+			if !appRes.HasError() {
+				response = appRes
 			} else {
-				err = fmt.Errorf("converse response error: %s", converseRes.ErrorMessage())
+				err = appRes.responseError("get app")
 			}
 		} else {
-			err = fmt.Errorf("converse parse error: %s", err)
+			err = fmt.Errorf("get app parse error: %w", err)
 		}
 	} else {
-		err = fmt.Errorf("converse request error: %s", err)
+		err = fmt.Errorf("get app request error: %w", err)
 	}
 
 	return response, err
 }
 
-func (c *Client) ConverseNext(sessionId string, context interface{}) (response Converse, err error) {
-	return c.ConverseFirst(sessionId, "", context)
-}
+// poll the app until its training is done, or given context.Context is done
+//
+// pollInterval defaults to DefaultTrainingPollInterval when it is not positive,
+// and ErrTrainingFailed is returned when the training status is neither done, scheduled, nor ongoing
+// (it keeps polling while the training status is missing)
+func (c *Client) WaitForTraining(ctx context.Context, appId string, pollInterval time.Duration) (err error) {
+	if pollInterval <= 0 {
+		pollInterval = DefaultTrainingPollInterval
+	}
 
-func (c *Client) ConverseAll(sessionId, query string, context interface{}) (responses []Converse, err error) {
-	if result, err := c.ConverseFirst(sessionId, query, context); err == nil {
-		responses = append(responses, result)
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
 
-		for {
-			if result, err := c.ConverseNext(sessionId, context); err == nil {
-				responses = append(responses, result)
+	for {
+		if err = c.waitForRateLimit(ctx); err != nil {
+			return fmt.Errorf("wait for training cancelled: %w", err)
+		}
 
-				if *result.Type != "stop" {
-					continue
-				}
-			} else {
-				return nil, err
+		var app App
+		if app, err = c.GetAppContext(ctx, appId); err != nil {
+			return fmt.Errorf("wait for training error: %w", err)
+		}
+
+		if app.TrainingStatus == nil { // (not reported yet)
+			c.verbose("* no training status of app %s yet", appId)
+		} else {
+			switch status := *app.TrainingStatus; status {
+			case TrainingStatusDone:
+				return nil
+			case TrainingStatusScheduled, TrainingStatusOngoing:
+				c.verbose("* training status of app %s: %s", appId, status)
+			default:
+				return fmt.Errorf("wait for training error: %w (status: '%s')", ErrTrainingFailed, status)
 			}
-			break
 		}
 
-		return responses, nil
-	} else {
-		return nil, err
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("wait for training cancelled: %w", ctx.Err())
+		case <-ticker.C:
+		}
 	}
 }
 
-// retrieve the list of all available entities
+// create a new app
 //
-// https://wit.ai/docs/http/20160516#get--entities-link
-func (c *Client) GetAllEntities() (response []string, err error) {
-	url := c.makeUrl("https://api.wit.ai/entities", nil)
-
-	var bytes []byte
-	if bytes, err = c.request("GET", *url, nil); err == nil {
-		var entitiesRes []string
-		if err = json.Unmarshal(bytes, &entitiesRes); err == nil {
-			response = entitiesRes
-		} else {
-			err = fmt.Errorf("get all entities parse error: %s", err)
-		}
-	} else {
-		err = fmt.Errorf("get all entities request error: %s", err)
-	}
-
-	return response, err
+// https://wit.ai/docs/http/20200513#post__apps_link
+func (c *Client) CreateApp(name, lang string, private bool) (response AppCreated, err error) {
+	return c.CreateAppContext(context.Background(), name, lang, private)
 }
 
-// create a new entity
+// create a new app with given context.Context
 //
-// https://wit.ai/docs/http/20160516#post--entities-link
-func (c *Client) CreateEntity(idOrName, doc *string, values ...EntityValue) (response Entity, err error) {
-	url := c.makeUrl("https://api.wit.ai/entities", nil)
+// https://wit.ai/docs/http/20200513#post__apps_link
+func (c *Client) CreateAppContext(ctx context.Context, name, lang string, private bool) (response AppCreated, err error) {
+	url := c.makeUrl("/apps", nil)
 
-	data := map[string]interface{}{
-		"id": *idOrName,
-	}
-	if doc != nil {
-		data["doc"] = *doc
-	}
-	if len(values) > 0 {
-		data["values"] = append([]EntityValue{}, values...)
+	body := map[string]interface{}{
+		"name":    name,
+		"lang":    lang,
+		"private": private,
 	}
 
 	var bytes []byte
-	if bytes, err = c.request("POST", *url, data); err == nil {
-		var entityRes Entity
-		if err = json.Unmarshal(bytes, &entityRes); err == nil {
-			if !entityRes.HasError() {
-				response = entityRes
+	if bytes, err = c.request(ctx, "POST", *url, body); err == nil {
+		var appRes AppCreated
+		if err = json.Unmarshal(bytes, &appRes); err == nil {
+			if !appRes.HasError() {
+				response = appRes
 			} else {
-				err = fmt.Errorf("new entity response error: %s", entityRes.ErrorMessage())
+				err = appRes.responseError("create app")
 			}
 		} else {
-			err = fmt.Errorf("new entity parse error: %s", err)
+			err = fmt.Errorf("create app parse error: %w", err)
 		}
 	} else {
-		err = fmt.Errorf("new entity request error: %s", err)
+		err = fmt.Errorf("create app request error: %w", err)
 	}
 
 	return response, err
 }
 
-// retrieve all values of an entity
+// update attributes of an app
 //
-// https://wit.ai/docs/http/20160516#get--entities-:entity-id-link
-func (c *Client) ShowEntity(entityId *string) (response Entity, err error) {
-	url := c.makeUrl(fmt.Sprintf("https://api.wit.ai/entities/%s", *entityId), nil)
+// https://wit.ai/docs/http/20200513#put__apps__app_link
+func (c *Client) UpdateApp(appId string, name, lang *string, private *bool) (response AppUpdated, err error) {
+	return c.UpdateAppContext(context.Background(), appId, name, lang, private)
+}
+
+// update attributes of an app with given context.Context
+//
+// https://wit.ai/docs/http/20200513#put__apps__app_link
+func (c *Client) UpdateAppContext(ctx context.Context, appId string, name, lang *string, private *bool) (response AppUpdated, err error) {
+	url := c.makeUrl(fmt.Sprintf("/apps/%s", url.PathEscape(appId)), nil)
+
+	body := map[string]interface{}{}
+	if name != nil {
+		body["name"] = *name
+	}
+	if lang != nil {
+		body["lang"] = *lang
+	}
+	if private != nil {
+		body["private"] = *private
+	}
 
 	var bytes []byte
-	if bytes, err = c.request("GET", *url, nil); err == nil {
-		var entityRes Entity
-		if err = json.Unmarshal(bytes, &entityRes); err == nil {
-			if !entityRes.HasError() {
-				response = entityRes
+	if bytes, err = c.request(ctx, "PUT", *url, body); err == nil {
+		var appRes AppUpdated
+		if err = json.Unmarshal(bytes, &appRes); err == nil {
+			if !appRes.HasError() {
+				response = appRes
 			} else {
-				err = fmt.Errorf("show entity response error: %s", entityRes.ErrorMessage())
+				err = appRes.responseError("update app")
 			}
 		} else {
-			err = fmt.Errorf("show entity parse error: %s", err)
+			err = fmt.Errorf("update app parse error: %w", err)
 		}
 	} else {
-		err = fmt.Errorf("show entity request error: %s", err)
+		err = fmt.Errorf("update app request error: %w", err)
 	}
 
 	return response, err
 }
 
-// update the values of an entity
+// delete an app
 //
-// https://wit.ai/docs/http/20160516#put--entities-:entity-id-link
-func (c *Client) UpdateEntity(entityId, doc *string, values ...EntityValue) (response Entity, err error) {
-	url := c.makeUrl(fmt.Sprintf("https://api.wit.ai/entities/%s", *entityId), nil)
+// https://wit.ai/docs/http/20200513#delete__apps__app_link
+func (c *Client) DeleteApp(appId string) (response DeletedResponse, err error) {
+	return c.DeleteAppContext(context.Background(), appId)
+}
 
-	body := map[string]interface{}{}
-	if doc != nil {
-		body["doc"] = *doc
-	}
-	if len(values) > 0 {
-		body["values"] = append([]EntityValue{}, values...)
-	}
+// delete an app with given context.Context
+//
+// https://wit.ai/docs/http/20200513#delete__apps__app_link
+func (c *Client) DeleteAppContext(ctx context.Context, appId string) (response DeletedResponse, err error) {
+	url := c.makeUrl(fmt.Sprintf("/apps/%s", url.PathEscape(appId)), nil)
 
 	var bytes []byte
-	if bytes, err = c.request("PUT", *url, body); err == nil {
-		var entityRes Entity
-		if err = json.Unmarshal(bytes, &entityRes); err == nil {
-			if !entityRes.HasError() {
-				response = entityRes
+	if bytes, err = c.request(ctx, "DELETE", *url, nil); err == nil {
+		var appRes DeletedResponse
+		if err = json.Unmarshal(bytes, &appRes); err == nil {
+			if !appRes.HasError() {
+				response = appRes
 			} else {
-				err = fmt.Errorf("update entity response error: %s", entityRes.ErrorMessage())
+				err = appRes.responseError("delete app")
 			}
 		} else {
-			err = fmt.Errorf("update entity parse error: %s", err)
+			err = fmt.Errorf("delete app parse error: %w (body: %s)", err, string(bytes))
 		}
 	} else {
-		err = fmt.Errorf("update entity request error: %s", err)
+		err = fmt.Errorf("delete app request error: %w", err)
 	}
 
 	return response, err
 }
 
-// delete an entity
+// retrieve all version tags of an app
 //
-// https://wit.ai/docs/http/20160516#delete--entities-:entity-id-link
-func (c *Client) DeleteEntity(entityId *string) (response map[string]string, err error) {
-	url := c.makeUrl(fmt.Sprintf("https://api.wit.ai/entities/%s", *entityId), nil)
+// tags are grouped by their snapshots
+//
+// https://wit.ai/docs/http/20200513#get__apps__app_tags_link
+func (c *Client) GetTags(appId string) (response [][]Tag, err error) {
+	return c.GetTagsContext(context.Background(), appId)
+}
+
+// retrieve all version tags of an app with given context.Context
+//
+// https://wit.ai/docs/http/20200513#get__apps__app_tags_link
+func (c *Client) GetTagsContext(ctx context.Context, appId string) (response [][]Tag, err error) {
+	url := c.makeUrl(fmt.Sprintf("/apps/%s/tags", url.PathEscape(appId)), nil)
 
 	var bytes []byte
-	if bytes, err = c.request("DELETE", *url, nil); err == nil {
-		var entityRes map[string]string
-		if err = json.Unmarshal(bytes, &entityRes); err == nil {
-			response = entityRes
+	if bytes, err = c.request(ctx, "GET", *url, nil); err == nil {
+		var tagsRes [][]Tag
+		if err = json.Unmarshal(bytes, &tagsRes); err == nil {
+			response = tagsRes
 		} else {
-			err = fmt.Errorf("delete entity parse error: %s", err)
+			err = fmt.Errorf("get tags parse error: %w", err)
 		}
 	} else {
-		err = fmt.Errorf("delete entity request error: %s", err)
+		err = fmt.Errorf("get tags request error: %w", err)
 	}
 
 	return response, err
 }
 
-// add new values to an entity
+// create a new version tag (snapshot) of an app
 //
-// https://wit.ai/docs/http/20160516#post--entities-:entity-id-values-link
-func (c *Client) CreateEntityValue(entityId, value *string, expressions []string, metadata *string) (response Entity, err error) {
-	url := c.makeUrl(fmt.Sprintf("https://api.wit.ai/entities/%s/values", *entityId), nil)
+// https://wit.ai/docs/http/20200513#post__apps__app_tags_link
+func (c *Client) CreateTag(appId, tag string) (response TagCreated, err error) {
+	return c.CreateTagContext(context.Background(), appId, tag)
+}
+
+// create a new version tag (snapshot) of an app with given context.Context
+//
+// https://wit.ai/docs/http/20200513#post__apps__app_tags_link
+func (c *Client) CreateTagContext(ctx context.Context, appId, tag string) (response TagCreated, err error) {
+	url := c.makeUrl(fmt.Sprintf("/apps/%s/tags", url.PathEscape(appId)), nil)
 
 	body := map[string]interface{}{
-		"value": *value,
-	}
-	if len(expressions) > 0 {
-		body["expressions"] = expressions
-	}
-	if metadata != nil {
-		body["metadata"] = *metadata
+		"tag": tag,
 	}
 
 	var bytes []byte
-	if bytes, err = c.request("POST", *url, body); err == nil {
-		var entityRes Entity
-		if err = json.Unmarshal(bytes, &entityRes); err == nil {
-			if !entityRes.HasError() {
-				response = entityRes
+	if bytes, err = c.request(ctx, "POST", *url, body); err == nil {
+		var tagRes TagCreated
+		if err = json.Unmarshal(bytes, &tagRes); err == nil {
+			if !tagRes.HasError() {
+				response = tagRes
 			} else {
-				err = fmt.Errorf("create entity value response error: %s", entityRes.ErrorMessage())
+				err = tagRes.responseError("create tag")
 			}
 		} else {
-			err = fmt.Errorf("create entity value parse error: %s", err)
+			err = fmt.Errorf("create tag parse error: %w", err)
 		}
 	} else {
-		err = fmt.Errorf("create entity value request error: %s", err)
+		err = fmt.Errorf("create tag request error: %w", err)
 	}
 
 	return response, err
 }
 
-// remove a given value from an entity
+// move a version tag of an app to another tag's snapshot
 //
-// https://wit.ai/docs/http/20160516#delete--entities-:entity-id-values-link
-func (c *Client) DeleteEntityValue(entityId, entityValue *string) (response map[string]string, err error) {
-	url := c.makeUrl(fmt.Sprintf("https://api.wit.ai/entities/%s/values/%s", *entityId, *entityValue), nil)
-
-	var bytes []byte
-	if bytes, err = c.request("DELETE", *url, nil); err == nil {
-		var entityRes map[string]string
-		if err = json.Unmarshal(bytes, &entityRes); err == nil {
-			response = entityRes
-		} else {
-			err = fmt.Errorf("delete entity value parse error: %s", err)
-		}
-	} else {
-		err = fmt.Errorf("delete entity value request error: %s", err)
-	}
-
-	return response, err
+// https://wit.ai/docs/http/20200513#put__apps__app_tags__tag_link
+func (c *Client) MoveTag(appId, tag, moveTo string) (response TagMoved, err error) {
+	return c.MoveTagContext(context.Background(), appId, tag, moveTo)
 }
 
-// create a new expression for an entity
+// move a version tag of an app to another tag's snapshot with given context.Context
 //
-// https://wit.ai/docs/http/20160516#post--entities-:entity-id-values-:value-id-expressions-link
-func (c *Client) CreateEntityExpression(entityId, entityValue, expression *string) (response Entity, err error) {
-	url := c.makeUrl(fmt.Sprintf("https://api.wit.ai/entities/%s/values/%s/expressions", *entityId, *entityValue), nil)
+// https://wit.ai/docs/http/20200513#put__apps__app_tags__tag_link
+func (c *Client) MoveTagContext(ctx context.Context, appId, tag, moveTo string) (response TagMoved, err error) {
+	url := c.makeUrl(fmt.Sprintf("/apps/%s/tags/%s", url.PathEscape(appId), url.PathEscape(tag)), nil)
 
 	body := map[string]interface{}{
-		"expression": *expression,
+		"move_to": moveTo,
 	}
 
 	var bytes []byte
-	if bytes, err = c.request("POST", *url, body); err == nil {
-		var entityRes Entity
-		if err = json.Unmarshal(bytes, &entityRes); err == nil {
-			if !entityRes.HasError() {
-				response = entityRes
+	if bytes, err = c.request(ctx, "PUT", *url, body); err == nil {
+		var tagRes TagMoved
+		if err = json.Unmarshal(bytes, &tagRes); err == nil {
+			if !tagRes.HasError() {
+				response = tagRes
 			} else {
-				err = fmt.Errorf("create entity expression response error: %s", entityRes.ErrorMessage())
+				err = tagRes.responseError("move tag")
 			}
 		} else {
-			err = fmt.Errorf("create entity expression parse error: %s", err)
+			err = fmt.Errorf("move tag parse error: %w", err)
 		}
 	} else {
-		err = fmt.Errorf("create entity expression request error: %s", err)
+		err = fmt.Errorf("move tag request error: %w", err)
 	}
 
 	return response, err
 }
 
-// remove an expression from an entity
+// delete a version tag of an app
 //
-// https://wit.ai/docs/http/20160516#delete--entities-:entity-id-values-:value-id-expressions-link
-func (c *Client) DeleteEntityExpression(entityId, entityValue, expression *string) (response map[string]string, err error) {
-	url := c.makeUrl(fmt.Sprintf("https://api.wit.ai/entities/%s/values/%s/expressions/%s", *entityId, *entityValue, *expression), nil)
+// https://wit.ai/docs/http/20200513#delete__apps__app_tags__tag_link
+func (c *Client) DeleteTag(appId, tag string) (response DeletedResponse, err error) {
+	return c.DeleteTagContext(context.Background(), appId, tag)
+}
+
+// delete a version tag of an app with given context.Context
+//
+// https://wit.ai/docs/http/20200513#delete__apps__app_tags__tag_link
+func (c *Client) DeleteTagContext(ctx context.Context, appId, tag string) (response DeletedResponse, err error) {
+	url := c.makeUrl(fmt.Sprintf("/apps/%s/tags/%s", url.PathEscape(appId), url.PathEscape(tag)), nil)
 
 	var bytes []byte
-	if bytes, err = c.request("DELETE", *url, nil); err == nil {
-		var entityRes map[string]string
-		if err = json.Unmarshal(bytes, &entityRes); err == nil {
-			response = entityRes
+	if bytes, err = c.request(ctx, "DELETE", *url, nil); err == nil {
+		var tagRes DeletedResponse
+		if err = json.Unmarshal(bytes, &tagRes); err == nil {
+			if !tagRes.HasError() {
+				response = tagRes
+			} else {
+				err = tagRes.responseError("delete tag")
+			}
 		} else {
-			err = fmt.Errorf("delete entity expression parse error: %s", err)
+			err = fmt.Errorf("delete tag parse error: %w (body: %s)", err, string(bytes))
 		}
 	} else {
-		err = fmt.Errorf("delete entity expression request error: %s", err)
+		err = fmt.Errorf("delete tag request error: %w", err)
 	}
 
 	return response, err
@@ -513,6 +3343,10 @@ func (c *Client) DeleteEntityExpression(entityId, entityValue, expression *strin
 // https://wit.ai/docs/http/20160330#intents-post-link
 // => https://wit.ai/docs/http/20160516#post--intents-(deprecated)-link
 func (c *Client) CreateIntent_deprecated(intents ...Intent) (response Intents, err error) {
+	if len(intents) == 0 {
+		return response, fmt.Errorf("new intents error: at least one intent is required")
+	}
+
 	var data interface{}
 
 	if len(intents) > 1 {
@@ -521,22 +3355,22 @@ func (c *Client) CreateIntent_deprecated(intents ...Intent) (response Intents, e
 		data = intents[0]
 	}
 
-	url := c.makeUrl("https://api.wit.ai/intents", nil)
+	url := c.makeUrl("/intents", nil)
 
 	var bytes []byte
-	if bytes, err = c.request("POST", *url, data); err == nil {
+	if bytes, err = c.request(withLegacyVersion(context.Background()), "POST", *url, data); err == nil {
 		var intentsRes Intents
 		if err = json.Unmarshal(bytes, &intentsRes); err == nil {
 			if !intentsRes.HasError() {
 				response = intentsRes
 			} else {
-				err = fmt.Errorf("new intents response error: %s", intentsRes.ErrorMessage())
+				err = intentsRes.responseError("new intents")
 			}
 		} else {
-			err = fmt.Errorf("new intents parse error: %s", err)
+			err = fmt.Errorf("new intents parse error: %w", err)
 		}
 	} else {
-		err = fmt.Errorf("new intents request error: %s", err)
+		err = fmt.Errorf("new intents request error: %w", err)
 	}
 
 	return response, err
@@ -547,18 +3381,18 @@ func (c *Client) CreateIntent_deprecated(intents ...Intent) (response Intents, e
 // https://wit.ai/docs/http/20160330#intents-index-link
 // => https://wit.ai/docs/http/20160516#get--intents-(deprecated)-link
 func (c *Client) GetAllIntents_deprecated() (response []Intent, err error) {
-	url := c.makeUrl("https://api.wit.ai/intents", nil)
+	url := c.makeUrl("/intents", nil)
 
 	var bytes []byte
-	if bytes, err = c.request("GET", *url, nil); err == nil {
+	if bytes, err = c.request(withLegacyVersion(context.Background()), "GET", *url, nil); err == nil {
 		var intentsRes []Intent
 		if err = json.Unmarshal(bytes, &intentsRes); err == nil {
 			response = intentsRes
 		} else {
-			err = fmt.Errorf("intent list parse error: %s", err)
+			err = fmt.Errorf("intent list parse error: %w", err)
 		}
 	} else {
-		err = fmt.Errorf("intent list request error: %s", err)
+		err = fmt.Errorf("intent list request error: %w", err)
 	}
 
 	return response, err
@@ -569,22 +3403,22 @@ func (c *Client) GetAllIntents_deprecated() (response []Intent, err error) {
 // https://wit.ai/docs/http/20160330#intent-show-link
 // => https://wit.ai/docs/http/20160516#get--intents-:intent-id-(deprecated)-link
 func (c *Client) ShowIntent_deprecated(intentIdOrName *string) (response IntentDetail, err error) {
-	url := c.makeUrl(fmt.Sprintf("https://api.wit.ai/intents/%s", *intentIdOrName), nil)
+	url := c.makeUrl(fmt.Sprintf("/intents/%s", url.PathEscape(*intentIdOrName)), nil)
 
 	var bytes []byte
-	if bytes, err = c.request("GET", *url, nil); err == nil {
+	if bytes, err = c.request(withLegacyVersion(context.Background()), "GET", *url, nil); err == nil {
 		var intentRes IntentDetail
 		if err = json.Unmarshal(bytes, &intentRes); err == nil {
 			if !intentRes.HasError() {
 				response = intentRes
 			} else {
-				err = fmt.Errorf("show intent response error: %s", intentRes.ErrorMessage())
+				err = intentRes.responseError("show intent")
 			}
 		} else {
-			err = fmt.Errorf("show intent parse error: %s", err)
+			err = fmt.Errorf("show intent parse error: %w", err)
 		}
 	} else {
-		err = fmt.Errorf("show intent request error: %s", err)
+		err = fmt.Errorf("show intent request error: %w", err)
 	}
 
 	return response, err
@@ -595,7 +3429,7 @@ func (c *Client) ShowIntent_deprecated(intentIdOrName *string) (response IntentD
 // https://wit.ai/docs/http/20160330#intent-put-link
 // => https://wit.ai/docs/http/20160516#put--intents-:intent-id-(deprecated)-link
 func (c *Client) UpdateIntentAttrs_deprecated(intentIdOrName, name, doc, metadata *string) (response IntentAttributes, err error) {
-	url := c.makeUrl(fmt.Sprintf("https://api.wit.ai/intents/%s", *intentIdOrName), nil)
+	url := c.makeUrl(fmt.Sprintf("/intents/%s", url.PathEscape(*intentIdOrName)), nil)
 
 	body := map[string]interface{}{}
 	if name != nil {
@@ -609,19 +3443,19 @@ func (c *Client) UpdateIntentAttrs_deprecated(intentIdOrName, name, doc, metadat
 	}
 
 	var bytes []byte
-	if bytes, err = c.request("PUT", *url, body); err == nil {
+	if bytes, err = c.request(withLegacyVersion(context.Background()), "PUT", *url, body); err == nil {
 		var intentRes IntentAttributes
 		if err = json.Unmarshal(bytes, &intentRes); err == nil {
 			if !intentRes.HasError() {
 				response = intentRes
 			} else {
-				err = fmt.Errorf("update intent attrs response error: %s", intentRes.ErrorMessage())
+				err = intentRes.responseError("update intent attrs")
 			}
 		} else {
-			err = fmt.Errorf("update intent attrs parse error: %s", err)
+			err = fmt.Errorf("update intent attrs parse error: %w", err)
 		}
 	} else {
-		err = fmt.Errorf("update intent attrs request error: %s", err)
+		err = fmt.Errorf("update intent attrs request error: %w", err)
 	}
 
 	return response, err
@@ -632,7 +3466,7 @@ func (c *Client) UpdateIntentAttrs_deprecated(intentIdOrName, name, doc, metadat
 // https://wit.ai/docs/http/20160330#create-intent-expressions-link
 // => https://wit.ai/docs/http/20160516#post--intents-:intent-id-expressions-(deprecated)-link
 func (c *Client) CreateIntentExpressions_deprecated(intentIdOrName *string, expressions ...string) (response []IntentExpressionCreated, err error) {
-	url := c.makeUrl(fmt.Sprintf("https://api.wit.ai/intents/%s/expressions", *intentIdOrName), nil)
+	url := c.makeUrl(fmt.Sprintf("/intents/%s/expressions", url.PathEscape(*intentIdOrName)), nil)
 
 	body := []interface{}{}
 	for _, expression := range expressions {
@@ -640,15 +3474,15 @@ func (c *Client) CreateIntentExpressions_deprecated(intentIdOrName *string, expr
 	}
 
 	var bytes []byte
-	if bytes, err = c.request("POST", *url, body); err == nil {
+	if bytes, err = c.request(withLegacyVersion(context.Background()), "POST", *url, body); err == nil {
 		var intentRes []IntentExpressionCreated
 		if err = json.Unmarshal(bytes, &intentRes); err == nil {
 			response = intentRes
 		} else {
-			err = fmt.Errorf("create intent expressions parse error: %s", err)
+			err = fmt.Errorf("create intent expressions parse error: %w", err)
 		}
 	} else {
-		err = fmt.Errorf("create intent expressions request error: %s", err)
+		err = fmt.Errorf("create intent expressions request error: %w", err)
 	}
 
 	return response, err
@@ -658,19 +3492,23 @@ func (c *Client) CreateIntentExpressions_deprecated(intentIdOrName *string, expr
 //
 // https://wit.ai/docs/http/20160330#destroy-intent-expression-link
 // => https://wit.ai/docs/http/20160516#delete--intents-:intent-id-expressions-:expression-id-(deprecated)-link
-func (c *Client) DeleteIntentExpression_deprecated(intentIdOrName, expressionId *string) (response map[string]string, err error) {
-	url := c.makeUrl(fmt.Sprintf("https://api.wit.ai/intents/%s/expressions/%s", *intentIdOrName, *expressionId), nil)
+func (c *Client) DeleteIntentExpression_deprecated(intentIdOrName, expressionId *string) (response DeletedResponse, err error) {
+	url := c.makeUrl(fmt.Sprintf("/intents/%s/expressions/%s", url.PathEscape(*intentIdOrName), url.PathEscape(*expressionId)), nil)
 
 	var bytes []byte
-	if bytes, err = c.request("DELETE", *url, nil); err == nil {
-		var exprRes map[string]string
+	if bytes, err = c.request(withLegacyVersion(context.Background()), "DELETE", *url, nil); err == nil {
+		var exprRes DeletedResponse
 		if err = json.Unmarshal(bytes, &exprRes); err == nil {
-			response = exprRes
+			if !exprRes.HasError() {
+				response = exprRes
+			} else {
+				err = exprRes.responseError("delete expression")
+			}
 		} else {
-			err = fmt.Errorf("delete expression parse error: %s", err)
+			err = fmt.Errorf("delete expression parse error: %w (body: %s)", err, string(bytes))
 		}
 	} else {
-		err = fmt.Errorf("delete expression request error: %s", err)
+		err = fmt.Errorf("delete expression request error: %w", err)
 	}
 
 	return response, err
@@ -678,25 +3516,28 @@ func (c *Client) DeleteIntentExpression_deprecated(intentIdOrName, expressionId
 
 // (DEPRECATED) retrieve an existing message
 //
+// the newer API has no endpoint for retrieving a stored message by its id;
+// stored utterances can only be listed with GetUtterances (and filtered by intents)
+//
 // https://wit.ai/docs/http/20160330#get-message-link
 // => https://wit.ai/docs/http/20160516#get--messages-:msg-id-(deprecated)-link
 func (c *Client) GetMessage_deprecated(messageId *string) (response Message, err error) {
-	url := c.makeUrl(fmt.Sprintf("https://api.wit.ai/messages/%s", *messageId), nil)
+	url := c.makeUrl(fmt.Sprintf("/messages/%s", url.PathEscape(*messageId)), nil)
 
 	var bytes []byte
-	if bytes, err = c.request("GET", *url, nil); err == nil {
+	if bytes, err = c.request(withLegacyVersion(context.Background()), "GET", *url, nil); err == nil {
 		var msgRes Message
 		if err = json.Unmarshal(bytes, &msgRes); err == nil {
 			if !msgRes.HasError() {
 				response = msgRes
 			} else {
-				err = fmt.Errorf("get message response error: %s", msgRes.ErrorMessage())
+				err = msgRes.responseError("get message")
 			}
 		} else {
-			err = fmt.Errorf("get message parse error: %s", err)
+			err = fmt.Errorf("get message parse error: %w", err)
 		}
 	} else {
-		err = fmt.Errorf("get message request error: %s", err)
+		err = fmt.Errorf("get message request error: %w", err)
 	}
 
 	return response, err
@@ -0,0 +1,896 @@
+package witai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// new client which sends requests to given function
+func newTestClient(f func(req *http.Request) (*http.Response, error)) *Client {
+	return NewClientWithOptions("test-token", WithDoer(DoerFunc(f)))
+}
+
+// new response with given status code, headers, and body
+func newTestResponse(statusCode int, header http.Header, body string) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     header,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestRedirects(t *testing.T) {
+	location := "https://example.com/export.zip"
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		return newTestResponse(http.StatusFound, http.Header{"Location": []string{location}}, ""), nil
+	})
+
+	// redirects are errors on other endpoints
+	if _, err := client.QueryMessage("hello", nil, "", ""); !errors.Is(err, ErrUnexpectedRedirect) {
+		t.Errorf("expected ErrUnexpectedRedirect from QueryMessage, but got: %v", err)
+	}
+	if _, err := client.ShowEntity(String("entity")); !errors.Is(err, ErrUnexpectedRedirect) {
+		t.Errorf("expected ErrUnexpectedRedirect from ShowEntity, but got: %v", err)
+	}
+
+	// location of a redirect is returned from ExportApp
+	if downloadURL, err := client.ExportApp(); err != nil {
+		t.Errorf("failed to export app: %s", err)
+	} else if downloadURL != location {
+		t.Errorf("expected download url '%s', but got '%s'", location, downloadURL)
+	}
+}
+
+func TestExportAppWithHTTPClientDoer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/export" {
+			http.Redirect(w, r, "/archive.zip", http.StatusFound)
+			return
+		}
+		t.Errorf("redirect should not be followed, but requested: %s", r.URL.Path)
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions("test-token", WithBaseURL(server.URL), WithDoer(server.Client()))
+
+	if downloadURL, err := client.ExportApp(); err != nil {
+		t.Errorf("failed to export app: %s", err)
+	} else if downloadURL != "/archive.zip" {
+		t.Errorf("expected download url '/archive.zip', but got '%s'", downloadURL)
+	}
+}
+
+func TestLegacyVersion(t *testing.T) {
+	var accept string
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		accept = req.Header.Get("Accept")
+		return newTestResponse(http.StatusOK, nil, `{}`), nil
+	})
+
+	// methods returning legacy responses are requested with LegacyVersion
+	if _, err := client.QueryMessage("hello", nil, "", ""); err != nil {
+		t.Errorf("failed to query message: %s", err)
+	} else if expected := "application/vnd.wit." + LegacyVersion + "+json"; accept != expected {
+		t.Errorf("expected accept header '%s', but got '%s'", expected, accept)
+	}
+
+	// others are requested with the client's version
+	if _, err := client.QueryMessageV2("hello", nil, 1); err != nil {
+		t.Errorf("failed to query message: %s", err)
+	} else if expected := "application/vnd.wit." + DefaultVersion + "+json"; accept != expected {
+		t.Errorf("expected accept header '%s', but got '%s'", expected, accept)
+	}
+}
+
+func TestLegacyRequests(t *testing.T) {
+	legacy, modern := "application/vnd.wit."+LegacyVersion+"+json", "application/vnd.wit."+DefaultVersion+"+json"
+
+	for _, test := range []struct {
+		name     string
+		call     func(c *Client) error
+		method   string
+		path     string
+		body     string // expected request body in json (empty for no body)
+		response string
+		accept   string
+	}{
+		{"create entity", func(c *Client) error {
+			_, err := c.CreateEntity(String("food"), String("doc"), NewEntityValue("pizza", "pie"))
+			return err
+		}, "POST", "/entities", `{"id": "food", "doc": "doc", "values": [{"value": "pizza", "expressions": ["pie"]}]}`, `{}`, legacy},
+		{"show entity", func(c *Client) error {
+			_, err := c.ShowEntity(String("food"))
+			return err
+		}, "GET", "/entities/food", ``, `{}`, legacy},
+		{"update entity", func(c *Client) error {
+			_, err := c.UpdateEntity(String("food"), String("doc"), NewEntityValue("pizza"))
+			return err
+		}, "PUT", "/entities/food", `{"doc": "doc", "values": [{"value": "pizza", "expressions": []}]}`, `{}`, legacy},
+		{"clear entity values", func(c *Client) error {
+			_, err := c.ClearEntityValues("food")
+			return err
+		}, "PUT", "/entities/food", `{"values": []}`, `{}`, legacy},
+		{"create entity value", func(c *Client) error {
+			_, err := c.CreateEntityValue(String("food"), String("pizza"), []string{"pie"}, nil)
+			return err
+		}, "POST", "/entities/food/values", `{"value": "pizza", "expressions": ["pie"]}`, `{}`, legacy},
+		{"delete entity value", func(c *Client) error {
+			_, err := c.DeleteEntityValue(String("food"), String("pizza"))
+			return err
+		}, "DELETE", "/entities/food/values/pizza", ``, `{}`, legacy},
+		{"create entity expression", func(c *Client) error {
+			_, err := c.CreateEntityExpression(String("food"), String("pizza"), String("pie"))
+			return err
+		}, "POST", "/entities/food/values/pizza/expressions", `{"expression": "pie"}`, `{}`, legacy},
+		{"delete entity expression", func(c *Client) error {
+			_, err := c.DeleteEntityExpression(String("food"), String("pizza"), String("pie"))
+			return err
+		}, "DELETE", "/entities/food/values/pizza/expressions/pie", ``, `{}`, legacy},
+		{"converse", func(c *Client) error {
+			_, err := c.ConverseFirst("session", "hello", map[string]interface{}{"state": "start"})
+			return err
+		}, "POST", "/converse", `{"state": "start"}`, `{"type": "stop"}`, legacy},
+		{"create intent", func(c *Client) error {
+			_, err := c.CreateIntent_deprecated(Intent{Name: String("greet")})
+			return err
+		}, "POST", "/intents", `{"name": "greet"}`, `{}`, legacy},
+		{"get all intents", func(c *Client) error {
+			_, err := c.GetAllIntents_deprecated()
+			return err
+		}, "GET", "/intents", ``, `[]`, legacy},
+		{"show intent", func(c *Client) error {
+			_, err := c.ShowIntent_deprecated(String("greet"))
+			return err
+		}, "GET", "/intents/greet", ``, `{}`, legacy},
+		{"update intent attrs", func(c *Client) error {
+			_, err := c.UpdateIntentAttrs_deprecated(String("greet"), nil, String("doc"), nil)
+			return err
+		}, "PUT", "/intents/greet", `{"doc": "doc"}`, `{}`, legacy},
+		{"create intent expressions", func(c *Client) error {
+			_, err := c.CreateIntentExpressions_deprecated(String("greet"), "hello")
+			return err
+		}, "POST", "/intents/greet/expressions", `[{"body": "hello"}]`, `[]`, legacy},
+		{"delete intent expression", func(c *Client) error {
+			_, err := c.DeleteIntentExpression_deprecated(String("greet"), String("1"))
+			return err
+		}, "DELETE", "/intents/greet/expressions/1", ``, `{}`, legacy},
+
+		// newer entity methods are requested with the client's version
+		{"create entity with lookups", func(c *Client) error {
+			_, err := c.CreateEntityWithLookups("food", []string{LookupKeywords})
+			return err
+		}, "POST", "/entities", `{"name": "food", "roles": [], "lookups": ["keywords"]}`, `{}`, modern},
+		{"update entity lookups", func(c *Client) error {
+			_, err := c.UpdateEntityLookups("food", []string{LookupFreeText})
+			return err
+		}, "PUT", "/entities/food", `{"name": "food", "lookups": ["free-text"]}`, `{}`, modern},
+	} {
+		var method, path, accept string
+		var body []byte
+		client := newTestClient(func(req *http.Request) (*http.Response, error) {
+			method, path, accept = req.Method, req.URL.Path, req.Header.Get("Accept")
+			if req.Body != nil {
+				body, _ = ioutil.ReadAll(req.Body)
+			} else {
+				body = nil
+			}
+			return newTestResponse(http.StatusOK, nil, test.response), nil
+		})
+
+		if err := test.call(client); err != nil {
+			t.Errorf("[%s] request failed: %s", test.name, err)
+			continue
+		}
+		if method != test.method || path != test.path {
+			t.Errorf("[%s] expected '%s %s', but requested '%s %s'", test.name, test.method, test.path, method, path)
+		}
+		if accept != test.accept {
+			t.Errorf("[%s] expected accept header '%s', but got '%s'", test.name, test.accept, accept)
+		}
+
+		if test.body == "" {
+			if len(body) > 0 {
+				t.Errorf("[%s] expected no body, but got: %s", test.name, string(body))
+			}
+			continue
+		}
+		var expected, sent interface{}
+		if err := json.Unmarshal([]byte(test.body), &expected); err != nil {
+			t.Fatalf("[%s] invalid expected body: %s", test.name, err)
+		}
+		if err := json.Unmarshal(body, &sent); err != nil {
+			t.Errorf("[%s] failed to decode sent body '%s': %s", test.name, string(body), err)
+		} else if !reflect.DeepEqual(expected, sent) {
+			t.Errorf("[%s] expected body %s, but sent %s", test.name, test.body, string(body))
+		}
+	}
+}
+
+func TestQueryMessageN(t *testing.T) {
+	var n string
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		n = req.URL.Query().Get("n")
+		return newTestResponse(http.StatusOK, nil, `{
+			"text": "hello",
+			"intents": [
+				{"id": "1", "name": "greet", "confidence": 0.7},
+				{"id": "2", "name": "bye", "confidence": 0.9},
+				{"id": "3", "name": "thanks", "confidence": 0.1}
+			],
+			"entities": {},
+			"traits": {}
+		}`), nil
+	})
+
+	response, err := client.QueryMessageN("hello", nil, "", "", 3)
+	if err != nil {
+		t.Fatalf("failed to query message: %s", err)
+	}
+	if n != "3" {
+		t.Errorf("expected n=3 in the query, but got n=%s", n)
+	}
+	if len(response.Intents) != 3 {
+		t.Fatalf("expected 3 intents, but got %d", len(response.Intents))
+	}
+	if sorted := response.IntentsByConfidence(); *sorted[0].Name != "bye" || *sorted[2].Name != "thanks" {
+		t.Errorf("intents are not sorted by confidence: %v", sorted)
+	}
+}
+
+func TestMakeUrlWithContext(t *testing.T) {
+	client := NewClient("test-token")
+
+	witContext := NewContext().WithTimeZone("Asia/Seoul").WithLocation(37.5, 127.0)
+	witContext.Entities = &Entities{
+		Id:     String("food"),
+		Values: []EntityValue{NewEntityValue("pizza", "pizza", "pie & cheese")},
+	}
+
+	requestURL := *client.makeUrl("/message", messageParams("hello?", witContext, "", ""))
+
+	parsed, err := url.Parse(requestURL)
+	if err != nil {
+		t.Fatalf("failed to parse url '%s': %s", requestURL, err)
+	}
+	if q := parsed.Query().Get("q"); q != "hello?" {
+		t.Errorf("expected q 'hello?', but got '%s'", q)
+	}
+
+	var decoded Context
+	if err := json.Unmarshal([]byte(parsed.Query().Get("context")), &decoded); err != nil {
+		t.Fatalf("failed to decode context from url '%s': %s", requestURL, err)
+	}
+	if decoded.TimeZone == nil || *decoded.TimeZone != "Asia/Seoul" {
+		t.Errorf("timezone was not kept: %s", decoded)
+	}
+	if decoded.Location == nil || decoded.Location.Latitude != 37.5 || decoded.Location.Longitude != 127.0 {
+		t.Errorf("location was not kept: %s", decoded)
+	}
+	if decoded.Entities == nil || len(decoded.Entities.Values) != 1 {
+		t.Fatalf("entities were not kept: %s", decoded)
+	}
+	if value := decoded.Entities.Values[0]; *value.Value != "pizza" || len(value.Expressions) != 2 || value.Expressions[1] != "pie & cheese" {
+		t.Errorf("entity value was not kept: %s", value)
+	}
+}
+
+func TestSpeechStream(t *testing.T) {
+	body := `{"text": "what's"}
+{"text": "what's the weather"}
+{"msg_id": "final", "_text": "what's the weather", "outcomes": [{"_text": "what's the weather", "intent": "weather", "confidence": 0.9}]}`
+
+	objects, err := splitJSONStream([]byte(body))
+	if err != nil {
+		t.Fatalf("failed to split json stream: %s", err)
+	}
+	if len(objects) != 3 {
+		t.Fatalf("expected 3 objects, but got %d", len(objects))
+	}
+
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		return newTestResponse(http.StatusOK, nil, body), nil
+	})
+
+	response, err := client.QuerySpeechReader(strings.NewReader("fake audio"), ContentTypeMp3, nil, "", "", 1)
+	if err != nil {
+		t.Fatalf("failed to query speech: %s", err)
+	}
+	if response.MessageID() != "final" {
+		t.Errorf("expected the final object, but got: %s", response)
+	}
+	if best, exists := response.BestOutcome(); !exists || *best.Intent != "weather" {
+		t.Errorf("expected outcome with intent 'weather', but got: %s", response)
+	}
+}
+
+func TestCreateIntentWithoutIntents(t *testing.T) {
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		t.Errorf("no request should be sent, but sent: %s %s", req.Method, req.URL)
+		return newTestResponse(http.StatusOK, nil, `[]`), nil
+	})
+
+	if _, err := client.CreateIntent_deprecated(); err == nil {
+		t.Errorf("expected an error for no intents, but got none")
+	}
+}
+
+func TestConverseAllWithoutType(t *testing.T) {
+	requests := 0
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		requests++
+		return newTestResponse(http.StatusOK, nil, `{"msg_id": "1", "confidence": 0.5}`), nil
+	})
+
+	responses, err := client.ConverseAll("session", "hello", nil, 0)
+	if err != nil {
+		t.Fatalf("failed to converse: %s", err)
+	}
+	if len(responses) != 1 || requests != 1 {
+		t.Errorf("a step without type should stop the conversation, but got %d step(s) with %d request(s)", len(responses), requests)
+	}
+}
+
+func TestEscapedEntityPath(t *testing.T) {
+	var rawPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawPath = r.URL.RawPath
+		w.Write([]byte(`{"id": "1", "name": "my food/drink"}`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions("test-token", WithBaseURL(server.URL))
+
+	if _, err := client.ShowEntity(String("my food/drink")); err != nil {
+		t.Fatalf("failed to show entity: %s", err)
+	}
+	if expected := "/entities/my%20food%2Fdrink"; rawPath != expected {
+		t.Errorf("expected raw path '%s', but got '%s'", expected, rawPath)
+	}
+}
+
+func TestConcurrentQueries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "1000")
+		w.Header().Set("X-RateLimit-Remaining", "999")
+		w.Write([]byte(`{"msg_id": "1", "_text": "hello", "outcomes": []}`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions("test-token", WithBaseURL(server.URL))
+
+	const queries = 50
+
+	var wg sync.WaitGroup
+	errs := make(chan error, queries)
+	for i := 0; i < queries; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if _, err := client.QueryMessage("hello", nil, "", ""); err != nil {
+				errs <- err
+			}
+			client.LastRateLimit()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("failed to query message concurrently: %s", err)
+	}
+}
+
+func TestEscapedEntityValuePath(t *testing.T) {
+	var rawPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawPath = r.URL.RawPath
+		w.Write([]byte(`{"deleted": "2024/01/01 a+b"}`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions("test-token", WithBaseURL(server.URL))
+
+	if _, err := client.DeleteEntityValue(String("date"), String("2024/01/01 a+b")); err != nil {
+		t.Fatalf("failed to delete entity value: %s", err)
+	}
+	if expected := "/entities/date/values/2024%2F01%2F01%20a%2Bb"; rawPath != expected {
+		t.Errorf("expected raw path '%s', but got '%s'", expected, rawPath)
+	}
+
+	if _, err := client.DeleteTraitValue("mood", "happy:+1"); err != nil {
+		t.Fatalf("failed to delete trait value: %s", err)
+	}
+	if expected := "/traits/mood/values/happy%3A%2B1"; rawPath != expected {
+		t.Errorf("expected raw path '%s', but got '%s'", expected, rawPath)
+	}
+
+	if _, err := client.DeleteEntityRole("city", "from:a+b"); err != nil {
+		t.Fatalf("failed to delete entity role: %s", err)
+	}
+	if expected := "/entities/city/roles/from%3Aa%2Bb"; rawPath != expected {
+		t.Errorf("expected raw path '%s', but got '%s'", expected, rawPath)
+	}
+}
+
+func TestDownloadExportWithDoer(t *testing.T) {
+	archive := "fake zip archive"
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/export" {
+			return newTestResponse(http.StatusOK, nil, `{"uri": "https://example.com/archive.zip"}`), nil
+		}
+		if req.Header.Get("Authorization") != "" {
+			t.Errorf("authorization header should not be sent to the download url")
+		}
+		return newTestResponse(http.StatusOK, nil, archive), nil
+	})
+
+	var buf strings.Builder
+	if err := client.DownloadExport(&buf); err != nil {
+		t.Fatalf("failed to download export: %s", err)
+	}
+	if buf.String() != archive {
+		t.Errorf("expected archive '%s', but got '%s'", archive, buf.String())
+	}
+}
+
+func TestRetrySpeechFromPartiallyReadReader(t *testing.T) {
+	var bodies []string
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		body, _ := ioutil.ReadAll(req.Body)
+		if int64(len(body)) != req.ContentLength {
+			t.Errorf("content length %d does not match body length %d", req.ContentLength, len(body))
+		}
+		bodies = append(bodies, string(body))
+
+		if len(bodies) == 1 {
+			return newTestResponse(http.StatusServiceUnavailable, nil, `{"error": "unavailable"}`), nil
+		}
+		return newTestResponse(http.StatusOK, nil, `{"msg_id": "1", "_text": "hello", "outcomes": []}`), nil
+	})
+	client.Retry = &RetryConfig{MaxAttempts: 2, RetrySpeech: true}
+
+	reader := bytes.NewReader([]byte("header:audio"))
+	reader.Seek(7, io.SeekStart) // skip "header:"
+
+	if _, err := client.QuerySpeechReader(reader, ContentTypeMp3, nil, "", "", 1); err != nil {
+		t.Fatalf("failed to query speech: %s", err)
+	}
+	if len(bodies) != 2 || bodies[0] != "audio" || bodies[1] != "audio" {
+		t.Errorf("expected 'audio' sent twice, but sent: %q", bodies)
+	}
+}
+
+func TestHTMLErrorResponses(t *testing.T) {
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		return newTestResponse(http.StatusNotFound, http.Header{"Content-Type": []string{"text/html"}}, "<html><body>Not Found</body></html>"), nil
+	})
+
+	_, err := client.ShowEntity(String("entity"))
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, but got: %v", err)
+	}
+	if !errors.Is(err, ErrUnexpectedResponse) {
+		t.Errorf("expected ErrUnexpectedResponse, but got: %v", err)
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !apiErr.HTML {
+		t.Errorf("expected *APIError with a html body, but got: %v", err)
+	}
+}
+
+func TestGetAllEntitiesIgnoringOffset(t *testing.T) {
+	names := make([]string, MaxEntitiesLimit)
+	for i := range names {
+		names[i] = fmt.Sprintf("entity%d", i)
+	}
+	page, _ := json.Marshal(names)
+
+	requests := 0
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		requests++
+		if requests > 3 {
+			t.Fatalf("too many requests for the same page")
+		}
+		return newTestResponse(http.StatusOK, nil, string(page)), nil // limit and offset are ignored
+	})
+
+	entities, err := client.GetAllEntities()
+	if err != nil {
+		t.Fatalf("failed to get all entities: %s", err)
+	}
+	if len(entities) != MaxEntitiesLimit {
+		t.Errorf("expected %d entities, but got %d", MaxEntitiesLimit, len(entities))
+	}
+}
+
+func TestRawJSON(t *testing.T) {
+	payload := `{"id": "1", "name": "food", "values": [], "unknown": true}`
+
+	var entity Entity
+	if err := json.Unmarshal([]byte(payload), &entity); err != nil {
+		t.Fatalf("failed to unmarshal entity: %s", err)
+	}
+	if string(entity.RawJSON) != payload {
+		t.Errorf("expected raw json '%s', but got '%s'", payload, string(entity.RawJSON))
+	}
+
+	// changes of fields are marshalled
+	entity.Values = []EntityValue{NewEntityValue("pizza")}
+	data, err := json.Marshal(entity)
+	if err != nil {
+		t.Fatalf("failed to marshal entity: %s", err)
+	}
+	var marshalled Entity
+	if err := json.Unmarshal(data, &marshalled); err != nil {
+		t.Fatalf("failed to unmarshal marshalled entity: %s", err)
+	}
+	if len(marshalled.Values) != 1 || *marshalled.Values[0].Value != "pizza" {
+		t.Errorf("changed values were not marshalled: %s", string(data))
+	}
+
+	// unknown fields are dropped by json.Marshal, but survive through RawJSON
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("failed to decode marshalled entity: %s", err)
+	}
+	if _, exists := fields["unknown"]; exists {
+		t.Errorf("unknown field should not be marshalled: %s", string(data))
+	}
+	fields = nil
+	if err := json.Unmarshal(entity.RawJSON, &fields); err != nil {
+		t.Fatalf("failed to decode raw json: %s", err)
+	}
+	if fields["unknown"] != true {
+		t.Errorf("unknown field was not kept in raw json: %s", string(entity.RawJSON))
+	}
+
+	// raw json of a filtered response is cleared
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		return newTestResponse(http.StatusOK, nil, `{"text": "hello", "intents": [{"id": "1", "name": "greet", "confidence": 0.1}], "unknown": true}`), nil
+	})
+	threshold := float32(0.5)
+	if response, err := client.QueryMessageWithOptions("hello", nil, MessageOptions{Threshold: &threshold}); err != nil {
+		t.Errorf("failed to query message: %s", err)
+	} else if len(response.Intents) != 0 || response.RawJSON != nil {
+		t.Errorf("expected a filtered response without raw json, but got intents %v and raw json '%s'", response.Intents, string(response.RawJSON))
+	}
+	if response, err := client.QueryMessageWithOptions("hello", nil, MessageOptions{}); err != nil {
+		t.Errorf("failed to query message: %s", err)
+	} else if !strings.Contains(string(response.RawJSON), `"unknown"`) {
+		t.Errorf("unknown field was not kept in raw json: '%s'", string(response.RawJSON))
+	}
+}
+
+func TestContextWithVersion(t *testing.T) {
+	if _, err := ContextWithVersion(context.Background(), "2020-05-13"); err == nil {
+		t.Errorf("expected an error for an invalid version, but got none")
+	}
+
+	ctx, err := ContextWithVersion(context.Background(), "20200513")
+	if err != nil {
+		t.Fatalf("failed to create context with version: %s", err)
+	}
+
+	var accept string
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		accept = req.Header.Get("Accept")
+		return newTestResponse(http.StatusOK, nil, `{}`), nil
+	})
+	if _, err := client.QueryMessageV2Context(ctx, "hello", nil, 1); err != nil {
+		t.Fatalf("failed to query message: %s", err)
+	}
+	if expected := "application/vnd.wit.20200513+json"; accept != expected {
+		t.Errorf("expected accept header '%s', but got '%s'", expected, accept)
+	}
+}
+
+func TestSetLocation(t *testing.T) {
+	nan := float32(math.NaN())
+
+	for _, invalid := range [][2]float32{{91, 0}, {0, -181}, {nan, 0}, {0, nan}} {
+		if err := NewContext().SetLocation(invalid[0], invalid[1]); err == nil {
+			t.Errorf("expected an error for location %v, but got none", invalid)
+		}
+	}
+
+	witContext := NewContext()
+	if err := witContext.SetLocation(37.5, 127.0); err != nil {
+		t.Errorf("failed to set location: %s", err)
+	} else if witContext.Location == nil || witContext.Location.Latitude != 37.5 {
+		t.Errorf("location was not set: %s", witContext)
+	}
+}
+
+func TestParseDatetimeInterval(t *testing.T) {
+	from, to := "2024-01-01T17:00:00.000-08:00", "2024-01-01T19:00:00.000-08:00"
+
+	var raw interface{}
+	if err := json.Unmarshal([]byte(`{"type": "interval", "from": {"grain": "hour", "value": "`+from+`"}, "to": {"grain": "hour", "value": "`+to+`"}}`), &raw); err != nil {
+		t.Fatalf("failed to decode raw entity: %s", err)
+	}
+
+	hour := String("hour")
+	for _, test := range []struct {
+		name     string
+		raw      interface{}
+		from, to string // empty for an open end
+		err      bool
+	}{
+		{"raw map", raw, from, to, false},
+		{"raw array", []interface{}{raw}, from, to, false},
+		{"detected entity", DetectedEntity{Type: String("interval"), From: &IntervalEnd{Value: from, Grain: hour}, To: &IntervalEnd{Value: to, Grain: hour}}, from, to, false},
+		{"entity resolution", EntityResolution{Type: String("interval"), From: &IntervalEnd{Value: from, Grain: hour}, To: &IntervalEnd{Value: to, Grain: hour}}, from, to, false},
+		{"open end", DetectedEntity{Type: String("interval"), From: &IntervalEnd{Value: from, Grain: hour}}, from, "", false},
+		{"open start", EntityResolution{Type: String("interval"), To: &IntervalEnd{Value: to, Grain: hour}}, "", to, false},
+		{"no ends", DetectedEntity{Type: String("interval")}, "", "", true},
+		{"not an interval", EntityResolution{Type: String("value"), Value: from}, "", "", false},
+	} {
+		parsedFrom, parsedTo, err := ParseDatetimeInterval(test.raw)
+		if test.err {
+			if err == nil {
+				t.Errorf("[%s] expected an error, but got none", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("[%s] failed to parse interval: %s", test.name, err)
+			continue
+		}
+
+		for _, end := range []struct {
+			label    string
+			parsed   *time.Time
+			expected string
+		}{{"from", parsedFrom, test.from}, {"to", parsedTo, test.to}} {
+			if end.expected == "" {
+				if end.parsed != nil {
+					t.Errorf("[%s] expected no '%s', but got %s", test.name, end.label, end.parsed)
+				}
+			} else if end.parsed == nil || end.parsed.Format(time.RFC3339) != mustParseRFC3339(t, end.expected).Format(time.RFC3339) {
+				t.Errorf("[%s] expected '%s' of %s, but got %v", test.name, end.label, end.expected, end.parsed)
+			}
+		}
+	}
+}
+
+func mustParseRFC3339(t *testing.T, value string) time.Time {
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatalf("failed to parse '%s': %s", value, err)
+	}
+	return parsed
+}
+
+func TestAppCreatedString(t *testing.T) {
+	app := AppCreated{AppId: String("1234"), AccessToken: String("secret-token")}
+
+	if str := fmt.Sprintf("%v", app); strings.Contains(str, "secret-token") {
+		t.Errorf("access token was not redacted: %s", str)
+	}
+}
+
+func TestUpdateApp(t *testing.T) {
+	var body string
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		data, _ := ioutil.ReadAll(req.Body)
+		body = string(data)
+		if strings.Contains(body, "invalid") {
+			return newTestResponse(http.StatusOK, nil, `{"error": "invalid lang", "code": "bad-request"}`), nil
+		}
+		return newTestResponse(http.StatusOK, nil, `{"success": true}`), nil
+	})
+
+	if response, err := client.UpdateApp("1234", String("app"), nil, nil); err != nil {
+		t.Errorf("failed to update app: %s", err)
+	} else if !response.Success {
+		t.Errorf("expected success, but got: %s", response)
+	} else if body != `{"name":"app"}` {
+		t.Errorf("unexpected request body: %s", body)
+	}
+
+	if _, err := client.UpdateApp("1234", nil, String("invalid"), nil); err == nil {
+		t.Errorf("expected an error from an error body, but got none")
+	} else if !strings.Contains(err.Error(), "invalid lang") {
+		t.Errorf("error message was not surfaced: %s", err)
+	}
+}
+
+func TestResponseErrorCodes(t *testing.T) {
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		return newTestResponse(http.StatusOK, nil, `{"error": "no such thing", "code": "not-found"}`), nil
+	})
+
+	for name, call := range map[string]func() error{
+		"show entity": func() error {
+			_, err := client.ShowEntity(String("food"))
+			return err
+		},
+		"delete trait value": func() error {
+			_, err := client.DeleteTraitValue("mood", "happy")
+			return err
+		},
+		"get app": func() error {
+			_, err := client.GetApp("1234")
+			return err
+		},
+		"message": func() error {
+			_, err := client.QueryMessageV2("hello", nil, 1)
+			return err
+		},
+		"message into": func() error {
+			var out map[string]interface{}
+			return client.QueryMessageInto("hello", nil, &out)
+		},
+	} {
+		if err := call(); !errors.Is(err, ErrNotFound) {
+			t.Errorf("[%s] expected an error which wraps ErrNotFound, but got: %v", name, err)
+		} else if !strings.Contains(err.Error(), "no such thing") {
+			t.Errorf("[%s] error message was not kept: %s", name, err)
+		}
+	}
+
+	// errors without a known code do not wrap any sentinel error
+	client = newTestClient(func(req *http.Request) (*http.Response, error) {
+		return newTestResponse(http.StatusOK, nil, `{"error": "something went wrong"}`), nil
+	})
+	if _, err := client.ShowEntity(String("food")); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("expected an error without a sentinel, but got: %v", err)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	retry := RetryConfig{Backoff: time.Second}
+
+	for attempt, expected := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 100: MaxRetryBackoff, math.MaxInt32: MaxRetryBackoff} {
+		if backoff := retry.backoff(attempt); backoff != expected {
+			t.Errorf("expected backoff %s for attempt %d, but got %s", expected, attempt, backoff)
+		}
+	}
+
+	// full jitter is between 0 and the backoff duration
+	retry.Jitter = true
+	for attempt := 1; attempt <= 100; attempt++ {
+		if backoff, max := retry.backoff(attempt), (&RetryConfig{Backoff: time.Second}).backoff(attempt); backoff < 0 || backoff > max {
+			t.Errorf("jittered backoff %s for attempt %d is out of range (0 ~ %s)", backoff, attempt, max)
+		}
+	}
+}
+
+func TestRetryMaxElapsedTime(t *testing.T) {
+	requests := 0
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		requests++
+		return newTestResponse(http.StatusServiceUnavailable, nil, `{"error": "unavailable"}`), nil
+	})
+	client.Retry = &RetryConfig{MaxAttempts: 10, Backoff: 20 * time.Millisecond, MaxElapsedTime: 50 * time.Millisecond}
+
+	// waits of 20ms and 40ms would exceed 50ms, so it gives up after the second attempt
+	started := time.Now()
+	if _, err := client.ShowEntity(String("food")); err == nil {
+		t.Errorf("expected an error, but got none")
+	} else if !strings.Contains(err.Error(), "after 2 attempts") {
+		t.Errorf("expected an error after 2 attempts, but got: %s", err)
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests, but sent %d", requests)
+	}
+	if elapsed := time.Since(started); elapsed > 50*time.Millisecond {
+		t.Errorf("retries took longer (%s) than MaxElapsedTime", elapsed)
+	}
+}
+
+func TestImportAppContentLength(t *testing.T) {
+	archive := []byte("fake zip archive")
+
+	var contentLength int64
+	var transferEncoding []string
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		contentLength, transferEncoding = req.ContentLength, req.TransferEncoding
+		return newTestResponse(http.StatusOK, nil, `{"app_id": "1234", "access_token": "token"}`), nil
+	})
+
+	if _, err := client.ImportApp("app", true, bytes.NewReader(archive)); err != nil {
+		t.Fatalf("failed to import app: %s", err)
+	}
+	if contentLength != int64(len(archive)) || len(transferEncoding) > 0 {
+		t.Errorf("expected content length %d without chunked encoding, but got %d (%v)", len(archive), contentLength, transferEncoding)
+	}
+}
+
+func TestWaitForTraining(t *testing.T) {
+	responses := []string{
+		`{"id": "1234", "name": "app"}`, // no training status yet
+		`{"id": "1234", "name": "app", "training_status": "ongoing"}`,
+		`{"id": "1234", "name": "app", "training_status": "done"}`,
+	}
+
+	requests := 0
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		response := responses[requests]
+		requests++
+		return newTestResponse(http.StatusOK, nil, response), nil
+	})
+
+	if err := client.WaitForTraining(context.Background(), "1234", time.Millisecond); err != nil {
+		t.Errorf("failed to wait for training: %s", err)
+	} else if requests != len(responses) {
+		t.Errorf("expected %d requests, but sent %d", len(responses), requests)
+	}
+
+	// unknown status is a failure
+	client = newTestClient(func(req *http.Request) (*http.Response, error) {
+		return newTestResponse(http.StatusOK, nil, `{"id": "1234", "training_status": "failed"}`), nil
+	})
+	if err := client.WaitForTraining(context.Background(), "1234", time.Millisecond); !errors.Is(err, ErrTrainingFailed) {
+		t.Errorf("expected ErrTrainingFailed, but got: %v", err)
+	}
+}
+
+func TestRateLimitWithoutRemaining(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-RateLimit-Limit", "1000")
+	header.Set("X-RateLimit-Reset", fmt.Sprintf("%d", time.Now().Add(time.Hour).Unix()))
+
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		return newTestResponse(http.StatusOK, header, `{"id": "1", "name": "food"}`), nil
+	})
+
+	if _, err := client.ShowEntity(String("food")); err != nil {
+		t.Fatalf("failed to show entity: %s", err)
+	}
+	if rateLimit, exists := client.LastRateLimit(); exists {
+		t.Errorf("rate limit should not be saved without remaining, but got: %s", rateLimit)
+	}
+
+	// it should not wait until the reset time
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := client.waitForRateLimit(ctx); err != nil {
+		t.Errorf("should not wait for rate limit, but got: %s", err)
+	}
+}
+
+func TestFormatTranscript(t *testing.T) {
+	steps := []Converse{
+		{Type: String("msg"), Message: String("hello"), Confidence: 0.5},
+		{ResponseError: ResponseError{Error: String("oops")}},
+		{},
+	}
+
+	transcript := strings.Split(FormatTranscriptWithQuery("hi", steps), "\n")
+	expected := []string{
+		"> user: hi",
+		"1. bot: hello (confidence: 0.500000)",
+		"2. error: oops",
+		"3. (no type)",
+	}
+	if !reflect.DeepEqual(transcript, expected) {
+		t.Errorf("expected transcript %q, but got %q", expected, transcript)
+	}
+
+	// confidence is printed with the same rule as String()
+	for _, step := range steps {
+		if strings.Contains(step.String(), "Confidence") != step.hasConfidence() {
+			t.Errorf("confidence of step %s does not follow the rule", step)
+		}
+	}
+}